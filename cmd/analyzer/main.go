@@ -4,14 +4,33 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"log-analyzer/internal/config"
+	"log-analyzer/internal/deploy"
 	"log-analyzer/internal/pipeline"
+	"log-analyzer/internal/preprocessor"
+	"log-analyzer/pkg/models"
 )
 
+const configPath = "./configs/config.yaml"
+
 func main() {
+	// Subcommands for recording deploy events; without one, run the analysis
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "annotate":
+			runAnnotate(os.Args[2:])
+			return
+		case "webhook":
+			runWebhook(os.Args[2:])
+			return
+		}
+	}
+
 	// Only one parameter: time range
 	timeRange := flag.String("time", "24h", "Time range for OpenSearch query (e.g., '1h', '24h', '7d')")
 	flag.Parse()
@@ -20,7 +39,7 @@ func main() {
 	fmt.Println()
 
 	// Load configuration
-	cfg, err := config.Load("./configs/config.yaml")
+	cfg, err := config.Load(configPath)
 	if err != nil {
 		log.Fatalf("❌ 無法加載配置：%v", err)
 	}
@@ -32,7 +51,7 @@ func main() {
 		log.Fatalf("❌ 管道執行失敗：%v", err)
 	}
 
-	if len(result.RawLogs) == 0 {
+	if result.TotalRawLogs == 0 {
 		os.Exit(0)
 	}
 
@@ -40,14 +59,79 @@ func main() {
 	printSummary(result, cfg)
 }
 
+// runAnnotate records a deploy event: analyzer annotate -service svc -version v1.2.3 [-time RFC3339]
+func runAnnotate(args []string) {
+	flags := flag.NewFlagSet("annotate", flag.ExitOnError)
+	service := flags.String("service", "", "Service that was deployed (required)")
+	version := flags.String("version", "", "Deployed version")
+	at := flags.String("time", "", "Deploy time in RFC3339 (default: now)")
+	description := flags.String("description", "", "Optional description of the change")
+	flags.Parse(args)
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		log.Fatalf("❌ 無法加載配置：%v", err)
+	}
+
+	event := models.DeployEvent{Service: *service, Version: *version, Description: *description, Time: time.Now().UTC()}
+	if *at != "" {
+		event.Time, err = time.Parse(time.RFC3339, *at)
+		if err != nil {
+			log.Fatalf("❌ 無效的時間 %q：%v", *at, err)
+		}
+	}
+
+	if err := newDeployStore(cfg).Append(event); err != nil {
+		log.Fatalf("❌ 無法記錄部署事件：%v", err)
+	}
+	fmt.Printf("✅ 已記錄部署事件：%s %s @ %s\n", event.Service, event.Version, event.Time.Format(time.RFC3339))
+}
+
+// runWebhook serves the deploy event webhook: POST /events with a JSON event or array of events
+func runWebhook(args []string) {
+	flags := flag.NewFlagSet("webhook", flag.ExitOnError)
+	addr := flags.String("addr", "", "Listen address (default: deploys.webhook.addr)")
+	flags.Parse(args)
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		log.Fatalf("❌ 無法加載配置：%v", err)
+	}
+	if *addr == "" {
+		*addr = cfg.Deploys.Webhook.Addr
+	}
+	// Without a token anyone who can reach the port could write events
+	if cfg.Deploys.Webhook.Token == "" && !deploy.IsLoopbackAddr(*addr) {
+		log.Fatalf("❌ webhook 監聽於非本機位址 %s 時必須設定 deploys.webhook.token", *addr)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/events", deploy.NewWebhookHandler(newDeployStore(cfg), cfg.Deploys.Webhook.Token))
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	fmt.Printf("🪝 部署事件 webhook 監聽於 %s/events\n", *addr)
+	if err := server.ListenAndServe(); err != nil {
+		log.Fatalf("❌ webhook 伺服器停止：%v", err)
+	}
+}
+
+// newDeployStore creates the deploy event store, normalizing services like parsed logs
+func newDeployStore(cfg *config.Config) *deploy.Store {
+	return deploy.NewStore(cfg.Deploys.EventsPath, preprocessor.NewServiceExtractorWithConfig(cfg.Preprocessing.ServiceExtraction))
+}
+
 // printSummary prints a summary of the pipeline execution
 func printSummary(result *pipeline.PipelineResult, cfg *config.Config) {
 	fmt.Println(strings.Repeat("=", 60))
 	fmt.Println("✨ 完整管道分析成功完成！")
 	fmt.Println(strings.Repeat("=", 60))
 	fmt.Printf("\n📊 最終統計資訊：\n")
-	fmt.Printf("   輸入日誌數：%d\n", len(result.RawLogs))
-	fmt.Printf("   解析日誌數：%d\n", len(result.ParsedLogs))
+	fmt.Printf("   輸入日誌數：%d\n", result.TotalRawLogs)
+	fmt.Printf("   解析日誌數：%d\n", result.TotalParsedLogs)
 	fmt.Printf("   錯誤群組數：%d\n", len(result.ErrorGroups))
 	fmt.Printf("   受影響服務數：%d\n", len(result.AggregationResult.ServiceStats))
 	fmt.Printf("   處理時間：%dms\n\n", result.AggregationResult.ProcessingTime.Milliseconds())
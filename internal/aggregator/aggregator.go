@@ -7,14 +7,32 @@ import (
 
 	"log-analyzer/internal/interfaces"
 	"log-analyzer/pkg/models"
+	"log-analyzer/pkg/sketch"
 )
 
 // LogAggregator implements the Aggregator interface
-type LogAggregator struct{}
+type LogAggregator struct {
+	location   *time.Location // timezone of hour and weekday buckets
+	peakWindow time.Duration  // sliding window of the overall peak
+}
 
 // NewLogAggregator creates a new log aggregator
 func NewLogAggregator() *LogAggregator {
-	return &LogAggregator{}
+	return &LogAggregator{location: time.Local, peakWindow: defaultPeakWindow}
+}
+
+// SetDisplayLocation sets the timezone used for hour-of-day, weekday and timeline buckets
+func (a *LogAggregator) SetDisplayLocation(loc *time.Location) {
+	if loc != nil {
+		a.location = loc
+	}
+}
+
+// SetPeakWindow sets the sliding window of the overall peak, matching the per-group peak window
+func (a *LogAggregator) SetPeakWindow(window time.Duration) {
+	if window > 0 {
+		a.peakWindow = window
+	}
 }
 
 // Aggregate performs statistical analysis on error groups
@@ -31,6 +49,9 @@ func (a *LogAggregator) Aggregate(groups []models.ErrorGroup) (*interfaces.Aggre
 	// Initialize time stats
 	result.TimeStats.HourlyDistribution = make(map[int]int)
 
+	// Union of entity sketches per service (service -> entity -> sketch)
+	entities := make(map[string]map[string]*sketch.HyperLogLog)
+
 	// Process each error group
 	var totalLogs int
 	for _, group := range groups {
@@ -51,6 +72,18 @@ func (a *LogAggregator) Aggregate(groups []models.ErrorGroup) (*interfaces.Aggre
 		serviceStats.ErrorGroupCount++
 		serviceStats.TotalErrors += group.TotalCount
 
+		// Count distinct affected entities across the service's groups
+		for name, s := range group.EntitySketches {
+			if entities[group.ServiceName] == nil {
+				entities[group.ServiceName] = make(map[string]*sketch.HyperLogLog)
+			}
+			if union, ok := entities[group.ServiceName][name]; ok {
+				union.Merge(s)
+			} else {
+				entities[group.ServiceName][name] = s.Clone()
+			}
+		}
+
 		// Update peak density for this service
 		if group.PeakWindow != nil && group.PeakWindow.Density > serviceStats.PeakDensity {
 			serviceStats.PeakDensity = group.PeakWindow.Density
@@ -67,12 +100,23 @@ func (a *LogAggregator) Aggregate(groups []models.ErrorGroup) (*interfaces.Aggre
 
 	result.TotalLogs = totalLogs
 
+	for serviceName, sketches := range entities {
+		counts := make(map[string]int, len(sketches))
+		for name, s := range sketches {
+			counts[name] = s.Count()
+		}
+		result.ServiceStats[serviceName].DistinctCounts = counts
+	}
+
 	// Calculate hourly distribution peaks
 	a.calculateHourlyStats(result.TimeStats)
 
-	// Calculate time range and peak window from error groups
+	// Calculate time range from error groups
 	a.calculateTimeRange(groups, result.TimeStats)
 
+	// Build the absolute timeline, weekday profile and peak window from every log
+	a.calculateTimeline(groups, result.TimeStats)
+
 	// Calculate average density
 	if len(groups) > 0 {
 		var totalDensity float64
@@ -89,83 +133,74 @@ func (a *LogAggregator) Aggregate(groups []models.ErrorGroup) (*interfaces.Aggre
 	return result, nil
 }
 
-// calculateTimeRange calculates the time range of logs
-func (a *LogAggregator) calculateTimeRange(groups []models.ErrorGroup, timeStats *interfaces.TimeStats) {
-	if len(groups) == 0 {
-		return
-	}
-
-	var earliest, latest time.Time
+// GroupByRootCause groups error groups by their innermost cause across callers and services,
+// sorted by total count descending
+func (a *LogAggregator) GroupByRootCause(groups []models.ErrorGroup) []interfaces.RootCauseGroup {
+	index := make(map[string]*interfaces.RootCauseGroup)
+	var order []string
 
 	for _, group := range groups {
-		for _, sample := range group.Samples {
-			if earliest.IsZero() || sample.Timestamp.Before(earliest) {
-				earliest = sample.Timestamp
-			}
-			if latest.IsZero() || sample.Timestamp.After(latest) {
-				latest = sample.Timestamp
-			}
+		cause := group.RootCause
+		if cause == "" {
+			cause = group.NormalizedContent
 		}
-	}
 
-	timeStats.EarliestLogTime = earliest
-	timeStats.LatestLogTime = latest
-	timeStats.QueryDuration = latest.Sub(earliest)
+		rc, exists := index[cause]
+		if !exists {
+			rc = &interfaces.RootCauseGroup{RootCause: cause}
+			index[cause] = rc
+			order = append(order, cause)
+		}
 
-	// Calculate the most dense 30-minute window
-	a.calculatePeakWindowFromGroups(groups, earliest, timeStats)
-}
+		rc.TotalCount += group.TotalCount
+		rc.Fingerprints = append(rc.Fingerprints, group.Fingerprint)
+		rc.Services = appendUnique(rc.Services, group.ServiceName)
+		if group.CallerFile != "" {
+			rc.Callers = appendUnique(rc.Callers, group.CallerFile)
+		}
+	}
 
-// calculatePeakWindowFromGroups finds the most dense 30-minute window across all error groups
-func (a *LogAggregator) calculatePeakWindowFromGroups(groups []models.ErrorGroup, baseTime time.Time, timeStats *interfaces.TimeStats) {
-	// Build 30-minute window distribution
-	windowDistribution := make(map[string]int) // "HH:MM" -> count
+	result := make([]interfaces.RootCauseGroup, 0, len(order))
+	for _, cause := range order {
+		result = append(result, *index[cause])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].TotalCount > result[j].TotalCount
+	})
 
-	for _, group := range groups {
-		for _, sample := range group.Samples {
-			hour := sample.Timestamp.Hour()
-			minute := sample.Timestamp.Minute()
-
-			// Determine which 30-minute window (00 or 30)
-			window := 0
-			if minute >= 30 {
-				window = 30
-			}
+	return result
+}
 
-			windowKey := fmt.Sprintf("%02d:%02d", hour, window)
-			windowDistribution[windowKey]++
+// appendUnique appends value if not already present
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
 		}
 	}
+	return append(values, value)
+}
 
-	// Find the window with maximum count
-	var peakWindowKey string
-	maxCount := 0
-	for key, count := range windowDistribution {
-		if count > maxCount {
-			maxCount = count
-			peakWindowKey = key
-		}
+// calculateTimeRange calculates the time range of logs
+func (a *LogAggregator) calculateTimeRange(groups []models.ErrorGroup, timeStats *interfaces.TimeStats) {
+	if len(groups) == 0 {
+		return
 	}
 
-	if peakWindowKey == "" {
-		return
+	var earliest, latest time.Time
+
+	for _, group := range groups {
+		if !group.FirstSeen.IsZero() && (earliest.IsZero() || group.FirstSeen.Before(earliest)) {
+			earliest = group.FirstSeen
+		}
+		if group.LastSeen.After(latest) {
+			latest = group.LastSeen
+		}
 	}
 
-	// Parse the peak window key and calculate actual times
-	var peakHour, peakMinute int
-	fmt.Sscanf(peakWindowKey, "%02d:%02d", &peakHour, &peakMinute)
-
-	// Use base time (EarliestLogTime) to calculate peak window
-	peakStart := time.Date(
-		baseTime.Year(), baseTime.Month(), baseTime.Day(),
-		peakHour, peakMinute, 0, 0,
-		baseTime.Location(),
-	)
-	peakEnd := peakStart.Add(30 * time.Minute)
-
-	timeStats.PeakWindowStart = peakStart
-	timeStats.PeakWindowEnd = peakEnd
-	timeStats.PeakWindowCount = maxCount
+	timeStats.EarliestLogTime = earliest
+	timeStats.LatestLogTime = latest
+	timeStats.QueryDuration = latest.Sub(earliest)
 }
 
 // calculateHourlyStats calculates peak hours and average density
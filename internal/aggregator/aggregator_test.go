@@ -0,0 +1,188 @@
+package aggregator
+
+import (
+	"testing"
+	"time"
+
+	"log-analyzer/internal/interfaces"
+	"log-analyzer/pkg/models"
+)
+
+func TestTimelineSpansDays(t *testing.T) {
+	taipei := time.FixedZone("CST", 8*3600)
+	day1 := time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC) // Monday 18:00 in Taipei
+	day3 := day1.Add(48*time.Hour + 20*time.Minute)      // Wednesday 18:20 in Taipei
+
+	groups := []models.ErrorGroup{
+		{
+			ServiceName: "pp-slot-api",
+			TotalCount:  13,
+			FirstSeen:   day1,
+			LastSeen:    day3.Add(2 * time.Minute),
+			TimeDistribution: map[string]int{
+				"18:00": 13,
+			},
+			TimeSeries: []models.TimeBucket{
+				{Start: day1, Count: 3},
+				{Start: day3, Count: 6},
+				{Start: day3.Add(2 * time.Minute), Count: 4},
+			},
+		},
+	}
+
+	agg := NewLogAggregator()
+	agg.SetDisplayLocation(taipei)
+	result, err := agg.Aggregate(groups)
+	if err != nil {
+		t.Fatalf("Aggregate returned error: %v", err)
+	}
+	ts := result.TimeStats
+
+	if !ts.PeakWindowStart.Equal(day3) || ts.PeakWindowCount != 10 {
+		t.Errorf("expected peak window at %s with 10 errors, got %s with %d", day3, ts.PeakWindowStart, ts.PeakWindowCount)
+	}
+	if len(ts.Timeline) != 49 {
+		t.Fatalf("expected 49 hourly buckets including empty hours, got %d", len(ts.Timeline))
+	}
+	if ts.Timeline[0].Count != 3 || ts.Timeline[48].Count != 10 || ts.Timeline[1].Count != 0 {
+		t.Errorf("unexpected timeline edges: %+v ... %+v", ts.Timeline[0], ts.Timeline[48])
+	}
+	if ts.DayOfWeekDistribution[time.Monday] != 3 || ts.DayOfWeekDistribution[time.Wednesday] != 10 {
+		t.Errorf("unexpected weekday profile: %v", ts.DayOfWeekDistribution)
+	}
+	if ts.PeakHour != 18 || ts.PeakCount != 13 {
+		t.Errorf("expected hour-of-day peak 18:00 (13), got %02d:00 (%d)", ts.PeakHour, ts.PeakCount)
+	}
+}
+
+func TestTimelineHalfHourOffsetAndPeakWindow(t *testing.T) {
+	kolkata := time.FixedZone("IST", 5*3600+1800)
+	start := time.Date(2025, 3, 3, 4, 30, 0, 0, time.UTC) // 10:00 in Kolkata
+
+	groups := []models.ErrorGroup{{
+		ServiceName: "pp-slot-api",
+		TotalCount:  9,
+		FirstSeen:   start,
+		LastSeen:    start.Add(70 * time.Minute),
+		TimeSeries: []models.TimeBucket{
+			{Start: start.Add(5 * time.Minute), Count: 2},  // 10:05
+			{Start: start.Add(55 * time.Minute), Count: 3}, // 10:55
+			{Start: start.Add(70 * time.Minute), Count: 4}, // 11:10
+		},
+	}}
+
+	agg := NewLogAggregator()
+	agg.SetDisplayLocation(kolkata)
+	agg.SetPeakWindow(20 * time.Minute)
+	result, err := agg.Aggregate(groups)
+	if err != nil {
+		t.Fatalf("Aggregate returned error: %v", err)
+	}
+	ts := result.TimeStats
+
+	// Buckets start on the display hour, not the UTC hour
+	if len(ts.Timeline) != 2 || !ts.Timeline[0].Start.Equal(start) || ts.Timeline[0].Count != 5 || ts.Timeline[1].Count != 4 {
+		t.Errorf("expected 10:00 (5) and 11:00 (4) display-hour buckets, got %+v", ts.Timeline)
+	}
+	// The configured window decides the overall peak
+	if !ts.PeakWindowStart.Equal(start.Add(55*time.Minute)) || ts.PeakWindowCount != 7 {
+		t.Errorf("expected a 20m peak at 10:55 with 7 errors, got %s with %d", ts.PeakWindowStart, ts.PeakWindowCount)
+	}
+}
+
+func TestTimelineAcrossDSTFallBack(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	// 2025-11-02 01:00 EDT repeats as 01:00 EST; span 00:00 EDT to 03:00 EST (5 absolute hours)
+	start := time.Date(2025, 11, 2, 4, 0, 0, 0, time.UTC)
+	end := start.Add(4*time.Hour + 10*time.Minute)
+	groups := []models.ErrorGroup{{
+		ServiceName: "pp-slot-api",
+		TotalCount:  7,
+		FirstSeen:   start,
+		LastSeen:    end,
+		TimeSeries: []models.TimeBucket{
+			{Start: start, Count: 1},
+			{Start: start.Add(time.Hour + 5*time.Minute), Count: 2},   // first 01:05
+			{Start: start.Add(2*time.Hour + 5*time.Minute), Count: 3}, // second 01:05
+			{Start: end, Count: 1},
+		},
+	}}
+
+	agg := NewLogAggregator()
+	agg.SetDisplayLocation(newYork)
+	done := make(chan *interfaces.AggregationResult, 1)
+	go func() {
+		result, _ := agg.Aggregate(groups)
+		done <- result
+	}()
+
+	select {
+	case result := <-done:
+		timeline := result.TimeStats.Timeline
+		if len(timeline) != 5 {
+			t.Fatalf("expected 5 hourly buckets across the fall-back, got %d", len(timeline))
+		}
+		if timeline[1].Count != 2 || timeline[2].Count != 3 {
+			t.Errorf("repeated 01:00 hours merged or lost: %+v", timeline)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Aggregate did not finish across the DST fall-back")
+	}
+}
+
+func TestAggregateByService(t *testing.T) {
+	start := time.Date(2025, 3, 3, 10, 0, 0, 0, time.UTC)
+	groups := []models.ErrorGroup{
+		{Fingerprint: "v2:aaaaaaaa11", ServiceName: "pp-slot-api", TotalCount: 100,
+			FirstSeen: start, LastSeen: start.Add(time.Hour),
+			TimeSeries: []models.TimeBucket{{Start: start, Count: 100}}},
+		{Fingerprint: "v2:bbbbbbbb22", ServiceName: "pp-slot-math", TotalCount: 4,
+			FirstSeen: start.Add(5 * time.Hour), LastSeen: start.Add(5 * time.Hour),
+			TimeSeries: []models.TimeBucket{{Start: start.Add(5 * time.Hour), Count: 4}}},
+		{Fingerprint: "v2:cccccccc33", ServiceName: "pp-slot-math", TotalCount: 2,
+			FirstSeen: start.Add(6 * time.Hour), LastSeen: start.Add(6 * time.Hour),
+			TimeSeries: []models.TimeBucket{{Start: start.Add(6 * time.Hour), Count: 2}}},
+	}
+
+	agg := NewLogAggregator()
+	global, err := agg.Aggregate(groups)
+	if err != nil {
+		t.Fatalf("Aggregate returned error: %v", err)
+	}
+	global.Cascades = []interfaces.CascadeChain{{OriginService: "pp-slot-api",
+		Effects: []interfaces.CascadeEffect{{Service: "pp-slot-math"}}}}
+
+	results, err := agg.AggregateByService(groups, global)
+	if err != nil {
+		t.Fatalf("AggregateByService returned error: %v", err)
+	}
+
+	mathResult := results["pp-slot-math"]
+	if mathResult.TotalLogs != 6 || mathResult.TotalErrorGroups != 2 || len(mathResult.ServiceStats) != 1 {
+		t.Errorf("expected pp-slot-math totals only, got %d logs, %d groups, %d services",
+			mathResult.TotalLogs, mathResult.TotalErrorGroups, len(mathResult.ServiceStats))
+	}
+	if !mathResult.TimeStats.PeakWindowStart.Equal(start.Add(5*time.Hour)) || mathResult.TimeStats.PeakWindowCount != 4 {
+		t.Errorf("expected pp-slot-math's own peak window, got %s (%d)", mathResult.TimeStats.PeakWindowStart, mathResult.TimeStats.PeakWindowCount)
+	}
+	if len(mathResult.Cascades) != 1 {
+		t.Errorf("expected the cascade involving pp-slot-math, got %d", len(mathResult.Cascades))
+	}
+
+	analyses := []models.Analysis{
+		{ErrorGroupID: "aaaaaaaa", Severity: models.SeverityHigh},
+		{ErrorGroupID: "bbbbbbbb", Severity: models.SeverityCritical},
+		{ErrorGroupID: "cccccccc", Severity: models.SeverityLow},
+	}
+	CountHighPriority(mathResult, analyses, groups)
+	CountHighPriority(global, analyses, groups)
+	if got := mathResult.ServiceStats["pp-slot-math"].HighPriorityCount; got != 1 {
+		t.Errorf("expected 1 high-priority pp-slot-math group, got %d", got)
+	}
+	if got := global.ServiceStats["pp-slot-api"].HighPriorityCount; got != 1 {
+		t.Errorf("expected 1 high-priority pp-slot-api group, got %d", got)
+	}
+}
@@ -0,0 +1,79 @@
+package aggregator
+
+import (
+	"log-analyzer/internal/interfaces"
+	"log-analyzer/pkg/models"
+)
+
+// AggregateByService aggregates each service's groups on their own, so per-service reports
+// show that service's timeline, peak window, group count and density. Run-level diagnostics
+// (parse failures, clock skew) are copied from global; root causes, correlations and cascades
+// are narrowed to the ones involving the service.
+func (a *LogAggregator) AggregateByService(groups []models.ErrorGroup, global *interfaces.AggregationResult) (map[string]*interfaces.AggregationResult, error) {
+	byService := make(map[string][]models.ErrorGroup)
+	for _, group := range groups {
+		byService[group.ServiceName] = append(byService[group.ServiceName], group)
+	}
+
+	results := make(map[string]*interfaces.AggregationResult, len(byService))
+	for service, serviceGroups := range byService {
+		result, err := a.Aggregate(serviceGroups)
+		if err != nil {
+			return nil, err
+		}
+
+		result.ParseFailures = global.ParseFailures
+		result.ClockSkew = global.ClockSkew
+		if global.RootCauses != nil {
+			result.RootCauses = a.GroupByRootCause(serviceGroups)
+		}
+		for _, c := range global.Correlations {
+			if c.UpstreamService == service || c.DownstreamService == service {
+				result.Correlations = append(result.Correlations, c)
+			}
+		}
+		for _, chain := range global.Cascades {
+			if cascadeInvolves(chain, service) {
+				result.Cascades = append(result.Cascades, chain)
+			}
+		}
+
+		results[service] = result
+	}
+
+	return results, nil
+}
+
+// cascadeInvolves reports whether the service is the origin or one of the effects of a chain
+func cascadeInvolves(chain interfaces.CascadeChain, service string) bool {
+	if chain.OriginService == service {
+		return true
+	}
+	for _, effect := range chain.Effects {
+		if effect.Service == service {
+			return true
+		}
+	}
+	return false
+}
+
+// CountHighPriority sets ServiceStats.HighPriorityCount from the severity of each group's analysis.
+// Analyses whose group belongs to a service missing from result are ignored.
+func CountHighPriority(result *interfaces.AggregationResult, analyses []models.Analysis, groups []models.ErrorGroup) {
+	serviceOf := make(map[string]string, len(groups))
+	for _, group := range groups {
+		serviceOf[models.ShortFingerprint(group.Fingerprint)] = group.ServiceName
+	}
+
+	for _, stats := range result.ServiceStats {
+		stats.HighPriorityCount = 0
+	}
+	for _, analysis := range analyses {
+		if analysis.Severity != models.SeverityHigh && analysis.Severity != models.SeverityCritical {
+			continue
+		}
+		if stats, ok := result.ServiceStats[serviceOf[analysis.ErrorGroupID]]; ok {
+			stats.HighPriorityCount++
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package aggregator
+
+import (
+	"sort"
+	"time"
+
+	"log-analyzer/internal/interfaces"
+	"log-analyzer/internal/normalizer"
+	"log-analyzer/pkg/models"
+)
+
+// defaultPeakWindow is the sliding window of the overall peak (TimeStats.PeakWindow*) unless set
+const defaultPeakWindow = 5 * time.Minute
+
+// calculateTimeline derives the hourly timeline, the weekday profile and the peak window from
+// the groups' time series, which count every log, so ranges spanning several days keep their dates
+func (a *LogAggregator) calculateTimeline(groups []models.ErrorGroup, timeStats *interfaces.TimeStats) {
+	buckets := make(map[time.Time]int)
+	hourly := make(map[time.Time]int)
+	timeStats.DayOfWeekDistribution = make(map[time.Weekday]int)
+
+	for _, group := range groups {
+		for _, bucket := range group.TimeSeries {
+			buckets[bucket.Start] += bucket.Count
+			hourly[hourStart(bucket.Start, a.location)] += bucket.Count
+			timeStats.DayOfWeekDistribution[bucket.Start.In(a.location).Weekday()] += bucket.Count
+		}
+	}
+	if len(buckets) == 0 {
+		return
+	}
+
+	// Sliding peak window over the merged series
+	series := make([]models.TimeBucket, 0, len(buckets))
+	for start, count := range buckets {
+		series = append(series, models.TimeBucket{Start: start, Count: count})
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].Start.Before(series[j].Start)
+	})
+	if peak := normalizer.CalculatePeakWindow(series, a.peakWindow); peak != nil {
+		timeStats.PeakWindowStart = peak.Start
+		timeStats.PeakWindowEnd = peak.End
+		timeStats.PeakWindowCount = peak.Count
+	}
+
+	// Hourly timeline from the first to the last hour, including empty hours. Hours are stepped in
+	// absolute time, so DST transitions in the display timezone neither repeat nor skip buckets.
+	first := hourStart(series[0].Start, a.location)
+	last := hourStart(series[len(series)-1].Start, a.location)
+	for hour := first; !hour.After(last); hour = hour.Add(time.Hour) {
+		timeStats.Timeline = append(timeStats.Timeline, models.TimeBucket{Start: hour, Count: hourly[hour]})
+	}
+}
+
+// hourStart returns the start of the display hour containing t, as an absolute time.
+// Subtracting the minutes past the local hour keeps zones with half-hour offsets aligned,
+// and the repeated hour of a DST fall-back stays two distinct buckets.
+func hourStart(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	past := time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second +
+		time.Duration(local.Nanosecond())
+	return t.Add(-past).UTC()
+}
@@ -3,19 +3,29 @@ package config
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"log-analyzer/pkg/models"
 )
 
 // Config represents the main configuration structure
 type Config struct {
-	OpenSearch OpenSearchConfig `yaml:"opensearch"`
-	Query      QueryConfig      `yaml:"query"`
-	Analysis   AnalysisConfig   `yaml:"analysis"`
-	Output     OutputConfig     `yaml:"output"`
-	Logging    LoggingConfig    `yaml:"logging"`
+	OpenSearch    OpenSearchConfig    `yaml:"opensearch"`
+	Query         QueryConfig         `yaml:"query"`
+	Volume        VolumeConfig        `yaml:"volume"`
+	Preprocessing PreprocessingConfig `yaml:"preprocessing"`
+	Redaction     RedactionConfig     `yaml:"redaction"`
+	Normalization NormalizationConfig `yaml:"normalization"`
+	Correlation   CorrelationConfig   `yaml:"correlation"`
+	Streaming     StreamingConfig     `yaml:"streaming"`
+	Analysis      AnalysisConfig      `yaml:"analysis"`
+	Deploys       DeploysConfig       `yaml:"deploys"`
+	Output        OutputConfig        `yaml:"output"`
+	Logging       LoggingConfig       `yaml:"logging"`
 }
 
 // OpenSearchConfig contains OpenSearch connection settings
@@ -34,6 +44,220 @@ type QueryConfig struct {
 	BatchSize int           `yaml:"batch_size"`
 }
 
+// VolumeConfig contains the denominator query of error ratios: total documents (or request logs) per service
+type VolumeConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Query is a query_string selecting request logs; empty counts every document
+	Query string `yaml:"query"`
+	// ServiceField is the keyword field holding the service name (default "fields.servicename")
+	ServiceField string `yaml:"service_field"`
+	// Interval is the histogram bucket size (default 5m); burn-rate short windows cannot be shorter
+	Interval time.Duration `yaml:"interval"`
+	// MinVolume is the least total volume for an hour to count towards the peak hourly ratio (default 100)
+	MinVolume int `yaml:"min_volume"`
+}
+
+// PreprocessingConfig contains raw log parsing settings
+type PreprocessingConfig struct {
+	// LevelAliases maps source levels (case-insensitive) to canonical levels,
+	// extending the built-in aliases (e.g. "notice": "info")
+	LevelAliases map[string]string `yaml:"level_aliases"`
+	// Workers is the number of concurrent parsing workers (default: number of CPUs)
+	Workers int `yaml:"workers"`
+	// ChunkSize is the number of raw logs handed to a worker at once (default: 1000)
+	ChunkSize int `yaml:"chunk_size"`
+	// Timestamps defines how the timestamp of each log is resolved
+	Timestamps TimestampConfig `yaml:"timestamps"`
+	// ServiceExtraction defines how service names are derived from raw logs
+	ServiceExtraction ServiceExtractionConfig `yaml:"service_extraction"`
+}
+
+// ServiceExtractionConfig contains service name extraction rules.
+// Empty lists fall back to the built-in defaults.
+type ServiceExtractionConfig struct {
+	// Sources lists raw log fields to try in order (e.g. "fields.servicename", "kubernetes.container.name", "index")
+	Sources []string `yaml:"sources"`
+	// StripPrefixes lists environment prefixes removed from extracted names
+	StripPrefixes []string `yaml:"strip_prefixes"`
+	// Patterns are regexes whose first capture group is the service name
+	Patterns []string `yaml:"patterns"`
+	// DenyList lists names never accepted as services (e.g. "filebeat")
+	DenyList []string `yaml:"deny_list"`
+}
+
+// TimestampConfig contains timestamp resolution settings.
+// Resolved timestamps are normalized to UTC; output.display_timezone controls presentation.
+type TimestampConfig struct {
+	// Order lists timestamp sources to try: inner (application JSON), wrapper (CRI prefix), source (document @timestamp)
+	Order []string `yaml:"order"`
+	// SourceTimezone is used for inner timestamps without a UTC offset (default: UTC)
+	SourceTimezone string `yaml:"source_timezone"`
+	// SkewThreshold flags pods whose inner/outer timestamps differ by more than this (default: 2m)
+	SkewThreshold time.Duration `yaml:"skew_threshold"`
+}
+
+// RedactionConfig contains PII and secret redaction settings.
+// Redaction runs between preprocessing and normalization, so samples, reports
+// and saved JSON only ever contain redacted values.
+type RedactionConfig struct {
+	// Disabled turns redaction off entirely
+	Disabled bool `yaml:"disabled"`
+	// Builtins selects built-in detectors (email, phone, jwt, bearer, card, mgckey); empty enables all
+	Builtins []string `yaml:"builtins"`
+	// Rules are additional regex detectors; a capture group limits redaction to that group
+	Rules []RedactionRule `yaml:"rules"`
+	// Fields maps log fields to the policy (mask, hash, drop) for values detected in them.
+	// content is always scanned (default: mask); listing other fields enables scanning them.
+	Fields map[string]string `yaml:"fields"`
+	// HashSalt is mixed into hashed values so they cannot be reversed by dictionary lookup
+	HashSalt string `yaml:"hash_salt"`
+}
+
+// RedactionRule is a configurable regex detector
+type RedactionRule struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+	Policy  string `yaml:"policy"` // optional; overrides the field policy
+}
+
+// Valid redaction settings, checked during validation
+var (
+	redactionPolicies = map[string]bool{"mask": true, "hash": true, "drop": true}
+	redactionBuiltins = map[string]bool{"email": true, "phone": true, "jwt": true, "bearer": true, "card": true, "mgckey": true}
+	redactionFields   = map[string]bool{
+		"content": true, "caller": true, "trace": true, "span": true,
+		"pod": true, "node": true, "namespace": true, "container": true,
+	}
+)
+
+// NormalizationConfig contains message normalization settings used for fingerprinting
+type NormalizationConfig struct {
+	// Maskers is the ordered list of maskers applied to lowercased content; empty enables all built-ins
+	Maskers []MaskerConfig `yaml:"maskers"`
+	// ReplaceLiterals replaces literals (case-insensitive) before masking, e.g. "lc-jade-prod": "<ENV>"
+	ReplaceLiterals map[string]string `yaml:"replace_literals"`
+	// Strategy selects the grouping strategy: "fingerprint" (exact match, default) or "drain" (template mining)
+	Strategy string `yaml:"strategy"`
+	// Drain configures the template miner used by the "drain" strategy
+	Drain DrainConfig `yaml:"drain"`
+	// Merge configures the near-duplicate group merging pass run after grouping
+	Merge MergeConfig `yaml:"merge"`
+	// ErrorChain configures decomposition of wrapped errors ("a: b: cause")
+	ErrorChain ErrorChainConfig `yaml:"error_chain"`
+	// Fingerprints configures fingerprint migration and manual aliases
+	Fingerprints FingerprintConfig `yaml:"fingerprints"`
+	// GroupBy selects what identifies a group within a service:
+	// content_caller (default), content (across callers) or caller (one group per code location)
+	GroupBy string `yaml:"group_by"`
+	// Caller configures caller normalization before grouping
+	Caller CallerConfig `yaml:"caller"`
+	// MaskedValues configures the most common original values kept per placeholder position
+	MaskedValues MaskedValuesConfig `yaml:"masked_values"`
+}
+
+// MaskedValuesConfig contains masked-value statistics settings
+type MaskedValuesConfig struct {
+	// Disabled turns masked-value statistics off
+	Disabled bool `yaml:"disabled"`
+	// TopK is the number of most common values kept per placeholder position (default 5)
+	TopK int `yaml:"top_k"`
+}
+
+// CallerConfig contains caller normalization settings
+type CallerConfig struct {
+	// StripLineNumbers drops ":213" so line shifts between deploys keep the same group
+	StripLineNumbers bool `yaml:"strip_line_numbers"`
+	// Keep selects the caller detail kept: full (default), file (base name) or package (directory)
+	Keep string `yaml:"keep"`
+	// PathMappings rewrites caller path prefixes, e.g. "github.com/acme/slot/": ""
+	PathMappings map[string]string `yaml:"path_mappings"`
+}
+
+// FingerprintConfig contains fingerprint stability settings
+type FingerprintConfig struct {
+	// RegistryPath persists known groups and new→old aliases (first-seen fingerprints stay canonical); empty disables migration
+	RegistryPath string `yaml:"registry_path"`
+	// Aliases force-merge groups: fingerprint -> canonical fingerprint
+	Aliases map[string]string `yaml:"aliases"`
+	// Splits force logs matching a pattern out of a group into their own group
+	Splits []FingerprintSplitConfig `yaml:"splits"`
+}
+
+// FingerprintSplitConfig splits logs whose raw content matches Pattern out of the group Fingerprint
+type FingerprintSplitConfig struct {
+	Fingerprint string `yaml:"fingerprint"`
+	Name        string `yaml:"name"`
+	Pattern     string `yaml:"pattern"`
+}
+
+// ErrorChainConfig contains wrapped error chain settings
+type ErrorChainConfig struct {
+	// Separators split the chain into segments (default ": ")
+	Separators []string `yaml:"separators"`
+	// GroupByRootCause adds a view grouping errors by innermost cause across callers and services
+	GroupByRootCause bool `yaml:"group_by_root_cause"`
+}
+
+// MergeConfig contains near-duplicate group merging settings
+type MergeConfig struct {
+	// Disabled turns merging off
+	Disabled bool `yaml:"disabled"`
+	// JaccardThreshold is the minimum token-set Jaccard index to merge two groups (default 0.8)
+	JaccardThreshold float64 `yaml:"jaccard_threshold"`
+	// EditSimilarityThreshold is the minimum 1 - token edit distance / length to merge two groups (default 0.9)
+	EditSimilarityThreshold float64 `yaml:"edit_similarity_threshold"`
+}
+
+// DrainConfig contains Drain template miner settings
+type DrainConfig struct {
+	// Depth of the parse tree, including the root and token-count layers (default 4)
+	Depth int `yaml:"depth"`
+	// SimilarityThreshold is the minimum fraction of matching tokens to join a template (default 0.4)
+	SimilarityThreshold float64 `yaml:"similarity_threshold"`
+	// MaxChildren limits children per tree node; extra tokens share a wildcard child (default 100)
+	MaxChildren int `yaml:"max_children"`
+	// StatePath persists learned templates between runs; empty keeps them in memory only
+	StatePath string `yaml:"state_path"`
+}
+
+// MaskerConfig is either a built-in masker or a regex masker.
+// A capture group in Pattern limits masking to that group.
+type MaskerConfig struct {
+	Builtin     string   `yaml:"builtin"` // uuid, email, url, path, ip, duration, hex, quoted, float, num
+	Name        string   `yaml:"name"`
+	Pattern     string   `yaml:"pattern"`
+	Placeholder string   `yaml:"placeholder"` // default: <NAME>
+	Services    []string `yaml:"services"`    // optional; empty applies to all services
+}
+
+// StreamingConfig contains bounded-memory pipeline settings
+type StreamingConfig struct {
+	// Enabled streams logs from fetching to grouping without keeping raw or parsed logs in memory
+	Enabled bool `yaml:"enabled"`
+	// BufferSize is the capacity of the channels between stages (default 1000)
+	BufferSize int `yaml:"buffer_size"`
+	// MaxTracesPerGroup bounds the trace IDs kept per group for correlation, in batch mode too (default 10000)
+	MaxTracesPerGroup int `yaml:"max_traces_per_group"`
+}
+
+// CorrelationConfig contains trace-based cross-service correlation settings
+type CorrelationConfig struct {
+	// Disabled turns correlation off
+	Disabled bool `yaml:"disabled"`
+	// MinSharedTraces is the number of shared trace IDs needed to link two groups (default 3)
+	MinSharedTraces int `yaml:"min_shared_traces"`
+	// MinOrderRatio is the share of shared traces in which upstream must occur first (default 0.6)
+	MinOrderRatio float64 `yaml:"min_order_ratio"`
+	// IncludeSameService also links groups of the same service
+	IncludeSameService bool `yaml:"include_same_service"`
+}
+
+// maskerBuiltins lists the built-in masker names, checked during validation
+var maskerBuiltins = map[string]bool{
+	"uuid": true, "email": true, "url": true, "path": true, "ip": true,
+	"duration": true, "hex": true, "quoted": true, "float": true, "num": true, "redacted_num": true,
+}
+
 // AnalysisConfig contains analysis parameters
 type AnalysisConfig struct {
 	TimeRange  string         `yaml:"time_range"`
@@ -42,6 +266,54 @@ type AnalysisConfig struct {
 	SampleSize int            `yaml:"sample_size"`
 	Density    DensityConfig  `yaml:"density"`
 	Severity   SeverityConfig `yaml:"severity"`
+	// Entities are fields extracted from log content whose distinct values are counted per group,
+	// in addition to the built-in trace, pod and host
+	Entities []EntityConfig `yaml:"entities"`
+	// ChangePoints finds onset and recovery of rate shifts per group and service
+	ChangePoints ChangePointConfig `yaml:"change_points"`
+	// SLO sets the error budget and burn-rate windows used with volume.enabled
+	SLO SLOConfig `yaml:"slo"`
+}
+
+// SLOConfig contains the SLO target and multi-window burn-rate alerts
+type SLOConfig struct {
+	// Target is the share of successful logs/requests, e.g. 0.999 (default)
+	Target float64 `yaml:"target"`
+	// Windows default to 1h/5m at 14.4×, 6h/30m at 6× (high) and 24h/2h at 3× (medium)
+	Windows []BurnWindowConfig `yaml:"windows"`
+}
+
+// BurnWindowConfig is a burn-rate alert firing when both windows burn at least BurnRate× the budget
+type BurnWindowConfig struct {
+	Long     time.Duration `yaml:"long"`
+	Short    time.Duration `yaml:"short"`
+	BurnRate float64       `yaml:"burn_rate"`
+	Severity string        `yaml:"severity"`
+}
+
+// ChangePointConfig contains CUSUM change-point detection settings
+type ChangePointConfig struct {
+	Disabled bool `yaml:"disabled"`
+	// Threshold is the CUSUM decision interval in baseline standard deviations (default 5)
+	Threshold float64 `yaml:"threshold"`
+	// Drift is the slack above the baseline, in standard deviations, before evidence accumulates (default 1)
+	Drift float64 `yaml:"drift"`
+	// MinCount drops episodes with fewer errors (default 10)
+	MinCount int `yaml:"min_count"`
+}
+
+// EntityConfig extracts an entity (e.g. player ID) from log content.
+// The first capture group of Pattern is the value; without one, the whole match.
+type EntityConfig struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+}
+
+// entityBuiltins lists the entities counted from log metadata
+var entityBuiltins = map[string]bool{
+	"trace": true,
+	"pod":   true,
+	"host":  true,
 }
 
 // DensityConfig contains time density analysis settings
@@ -56,6 +328,20 @@ type SeverityConfig struct {
 	CountThresholds   CountThresholds   `yaml:"count_thresholds"`
 	DensityThresholds DensityThresholds `yaml:"density_thresholds"`
 	Trend             TrendConfig       `yaml:"trend"`
+	// Basis selects what the thresholds apply to: "count" (log lines), "ratio" (errors over the
+	// service's volume, default with volume.enabled) or an entity (trace, pod, host or an
+	// analysis.entities name) to rate groups by affected entities
+	Basis string `yaml:"basis"`
+	// EntityThresholds are the distinct entity counts for high and medium severity when Basis is an entity
+	EntityThresholds CountThresholds `yaml:"entity_thresholds"`
+	// RatioThresholds are the peak hourly error ratios for high and medium severity when Basis is "ratio"
+	RatioThresholds RatioThresholds `yaml:"ratio_thresholds"`
+}
+
+// RatioThresholds defines error-ratio severity thresholds (fractions, 0.05 = 5%)
+type RatioThresholds struct {
+	High   float64 `yaml:"high"`
+	Medium float64 `yaml:"medium"`
 }
 
 // CountThresholds defines count-based severity thresholds
@@ -74,12 +360,60 @@ type DensityThresholds struct {
 
 // TrendConfig contains trend analysis settings
 type TrendConfig struct {
+	// Disabled turns off baselines, anomaly flags and history recording
+	Disabled bool `yaml:"disabled"`
+	// HistoryPath is the JSON file holding hourly counts from previous runs
+	HistoryPath string `yaml:"history_path"`
+	// RetentionDays is how much history is kept (default 28, four weeks of hour-of-week samples)
+	RetentionDays int `yaml:"retention_days"`
+	// AnomalyThreshold is the z-score at or above which a rate is anomalous (default 3)
 	AnomalyThreshold float64 `yaml:"anomaly_threshold"`
+	// MinPercentChange is the minimum increase over the baseline, in percent, for an anomaly
+	MinPercentChange float64 `yaml:"min_percent_change"`
+	// MinCount is the minimum count in the run before a group can be anomalous (default 3)
+	MinCount int `yaml:"min_count"`
+	// EWMAAlpha is the smoothing factor of the EWMA baseline, in (0, 1] (default 0.3)
+	EWMAAlpha float64 `yaml:"ewma_alpha"`
+}
+
+// DeploysConfig contains deploy event ingestion and correlation settings
+type DeploysConfig struct {
+	// EventsPath is the NDJSON store written by the annotate command and the webhook
+	EventsPath string `yaml:"events_path"`
+	// Files are extra YAML or NDJSON event files, e.g. exported by CI
+	Files []string `yaml:"files"`
+	// WindowMinutes is how long after a deploy a group onset is attributed to it (default 30)
+	WindowMinutes int `yaml:"window_minutes"`
+	// Webhook receives events over HTTP
+	Webhook WebhookConfig `yaml:"webhook"`
+}
+
+// WebhookConfig contains the deploy webhook server settings
+type WebhookConfig struct {
+	Addr string `yaml:"addr"` // listen address (default "127.0.0.1:8090")
+	// Token, when set, is required as "Authorization: Bearer <token>";
+	// the webhook refuses to listen on a non-loopback address without one
+	Token string `yaml:"token"`
 }
 
 // OutputConfig contains output settings
 type OutputConfig struct {
-	ReportDir string `yaml:"report_dir"`
+	ReportDir     string `yaml:"report_dir"`
+	DeadLetterDir string `yaml:"dead_letter_dir"`
+	// DisplayTimezone is the IANA timezone used in reports (default: Local)
+	DisplayTimezone string `yaml:"display_timezone"`
+}
+
+// Location returns the display timezone, falling back to the local timezone
+func (c OutputConfig) Location() *time.Location {
+	if c.DisplayTimezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(c.DisplayTimezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
 }
 
 // LoggingConfig contains logging settings
@@ -161,9 +495,85 @@ func applyDefaults(config *Config) {
 	if config.Analysis.Density.CriticalDensityThreshold == 0 {
 		config.Analysis.Density.CriticalDensityThreshold = 500
 	}
+	if config.Analysis.Severity.EntityThresholds.High == 0 {
+		config.Analysis.Severity.EntityThresholds.High = 50
+	}
+	if config.Analysis.Severity.EntityThresholds.Medium == 0 {
+		config.Analysis.Severity.EntityThresholds.Medium = 10
+	}
+	if config.Analysis.Severity.Trend.HistoryPath == "" {
+		config.Analysis.Severity.Trend.HistoryPath = "./data/trend-history.json"
+	}
+	if config.Analysis.Severity.Trend.RetentionDays == 0 {
+		config.Analysis.Severity.Trend.RetentionDays = 28
+	}
+	if config.Analysis.Severity.Trend.AnomalyThreshold == 0 {
+		config.Analysis.Severity.Trend.AnomalyThreshold = 3
+	}
+	if config.Analysis.Severity.Trend.MinCount == 0 {
+		config.Analysis.Severity.Trend.MinCount = 3
+	}
+	if config.Analysis.Severity.Trend.EWMAAlpha == 0 {
+		config.Analysis.Severity.Trend.EWMAAlpha = 0.3
+	}
+	if config.Volume.ServiceField == "" {
+		config.Volume.ServiceField = "fields.servicename"
+	}
+	if config.Volume.Interval == 0 {
+		config.Volume.Interval = 5 * time.Minute
+	}
+	if config.Volume.MinVolume == 0 {
+		config.Volume.MinVolume = 100
+	}
+	if config.Volume.Enabled && config.Analysis.Severity.Basis == "" {
+		config.Analysis.Severity.Basis = "ratio"
+	}
+	if config.Analysis.Severity.RatioThresholds.High == 0 {
+		config.Analysis.Severity.RatioThresholds.High = 0.05
+	}
+	if config.Analysis.Severity.RatioThresholds.Medium == 0 {
+		config.Analysis.Severity.RatioThresholds.Medium = 0.01
+	}
+	if config.Analysis.SLO.Target == 0 {
+		config.Analysis.SLO.Target = 0.999
+	}
+	if len(config.Analysis.SLO.Windows) == 0 {
+		config.Analysis.SLO.Windows = []BurnWindowConfig{
+			{Long: time.Hour, Short: 5 * time.Minute, BurnRate: 14.4, Severity: "high"},
+			{Long: 6 * time.Hour, Short: 30 * time.Minute, BurnRate: 6, Severity: "high"},
+			{Long: 24 * time.Hour, Short: 2 * time.Hour, BurnRate: 3, Severity: "medium"},
+		}
+	}
+	if config.Analysis.ChangePoints.Threshold == 0 {
+		config.Analysis.ChangePoints.Threshold = 5
+	}
+	if config.Analysis.ChangePoints.Drift == 0 {
+		config.Analysis.ChangePoints.Drift = 1
+	}
+	if config.Analysis.ChangePoints.MinCount == 0 {
+		config.Analysis.ChangePoints.MinCount = 10
+	}
+	if config.Deploys.EventsPath == "" {
+		config.Deploys.EventsPath = "./data/deploy-events.ndjson"
+	}
+	if config.Deploys.WindowMinutes == 0 {
+		config.Deploys.WindowMinutes = 30
+	}
+	if config.Deploys.Webhook.Addr == "" {
+		config.Deploys.Webhook.Addr = "127.0.0.1:8090"
+	}
+	if config.Streaming.BufferSize == 0 {
+		config.Streaming.BufferSize = 1000
+	}
+	if config.Streaming.MaxTracesPerGroup == 0 {
+		config.Streaming.MaxTracesPerGroup = 10000
+	}
 	if config.Output.ReportDir == "" {
 		config.Output.ReportDir = "./reports"
 	}
+	if config.Output.DeadLetterDir == "" {
+		config.Output.DeadLetterDir = "./data/dead-letter"
+	}
 	if len(config.Analysis.Keywords) == 0 {
 		config.Analysis.Keywords = []string{"error"} // Default to searching for errors
 	}
@@ -192,5 +602,246 @@ func validate(config *Config) error {
 	if config.Analysis.SampleSize <= 0 {
 		return fmt.Errorf("analysis.sample_size must be positive")
 	}
+	for alias, level := range config.Preprocessing.LevelAliases {
+		if !models.LogLevel(strings.ToLower(level)).IsValid() {
+			return fmt.Errorf("preprocessing.level_aliases.%s: unknown canonical level %q", alias, level)
+		}
+	}
+	if err := validateEntities(&config.Analysis); err != nil {
+		return err
+	}
+	if err := validateTrend(&config.Analysis.Severity.Trend); err != nil {
+		return err
+	}
+	if cp := config.Analysis.ChangePoints; cp.Threshold < 0 || cp.Drift < 0 || cp.MinCount < 0 {
+		return fmt.Errorf("analysis.change_points: threshold, drift and min_count cannot be negative")
+	}
+	if err := validateVolume(config); err != nil {
+		return err
+	}
+	if config.Deploys.WindowMinutes < 0 {
+		return fmt.Errorf("deploys.window_minutes cannot be negative")
+	}
+	if err := validateTimestamps(config); err != nil {
+		return err
+	}
+	if err := validateRedaction(&config.Redaction); err != nil {
+		return err
+	}
+	if err := validateMaskers(config.Normalization.Maskers); err != nil {
+		return err
+	}
+	if err := validateNormalization(&config.Normalization); err != nil {
+		return err
+	}
+	if config.Streaming.Enabled && config.Normalization.Strategy == "drain" {
+		return fmt.Errorf("streaming.enabled: the drain strategy needs the whole input and cannot stream")
+	}
+	if config.Streaming.BufferSize < 0 || config.Streaming.MaxTracesPerGroup < 0 {
+		return fmt.Errorf("streaming.buffer_size and streaming.max_traces_per_group cannot be negative")
+	}
+	if config.Correlation.MinSharedTraces < 0 {
+		return fmt.Errorf("correlation.min_shared_traces cannot be negative")
+	}
+	if config.Correlation.MinOrderRatio < 0 || config.Correlation.MinOrderRatio > 1 {
+		return fmt.Errorf("correlation.min_order_ratio must be between 0 and 1")
+	}
+	for _, pattern := range config.Preprocessing.ServiceExtraction.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("preprocessing.service_extraction.patterns: invalid regex %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// validateEntities checks entity fields and the severity basis that may refer to them
+func validateEntities(cfg *AnalysisConfig) error {
+	names := make(map[string]bool, len(cfg.Entities))
+	for i, entity := range cfg.Entities {
+		if entity.Name == "" || entity.Pattern == "" {
+			return fmt.Errorf("analysis.entities[%d]: name and pattern are required", i)
+		}
+		if entityBuiltins[entity.Name] || names[entity.Name] {
+			return fmt.Errorf("analysis.entities.%s: duplicate entity name", entity.Name)
+		}
+		if _, err := regexp.Compile(entity.Pattern); err != nil {
+			return fmt.Errorf("analysis.entities.%s: invalid regex: %w", entity.Name, err)
+		}
+		names[entity.Name] = true
+	}
+
+	basis := cfg.Severity.Basis
+	if basis != "" && basis != "count" && basis != "ratio" && !entityBuiltins[basis] && !names[basis] {
+		return fmt.Errorf("analysis.severity.basis: unknown entity %q (expected count, ratio, trace, pod, host or an analysis.entities name)", basis)
+	}
+	return nil
+}
+
+// validateVolume checks the denominator query, SLO windows and the ratio severity basis
+func validateVolume(config *Config) error {
+	if config.Analysis.Severity.Basis == "ratio" && !config.Volume.Enabled {
+		return fmt.Errorf("analysis.severity.basis: ratio requires volume.enabled")
+	}
+	if config.Volume.Enabled && config.Volume.Interval < time.Minute {
+		return fmt.Errorf("volume.interval must be at least 1m")
+	}
+	if config.Volume.MinVolume < 0 {
+		return fmt.Errorf("volume.min_volume cannot be negative")
+	}
+	if t := config.Analysis.SLO.Target; t <= 0 || t >= 1 {
+		return fmt.Errorf("analysis.slo.target must be between 0 and 1 (exclusive)")
+	}
+	for i, w := range config.Analysis.SLO.Windows {
+		if w.Long <= 0 || w.Short <= 0 || w.Short > w.Long {
+			return fmt.Errorf("analysis.slo.windows[%d]: long and short must be positive with short <= long", i)
+		}
+		if config.Volume.Enabled && w.Short < config.Volume.Interval {
+			return fmt.Errorf("analysis.slo.windows[%d]: short window %s is shorter than volume.interval", i, w.Short)
+		}
+		if w.BurnRate <= 0 {
+			return fmt.Errorf("analysis.slo.windows[%d]: burn_rate must be positive", i)
+		}
+		if !models.Severity(w.Severity).IsValid() {
+			return fmt.Errorf("analysis.slo.windows[%d]: unknown severity %q", i, w.Severity)
+		}
+	}
+	t := config.Analysis.Severity.RatioThresholds
+	if t.Medium < 0 || t.High < t.Medium || t.High > 1 {
+		return fmt.Errorf("analysis.severity.ratio_thresholds: expected 0 <= medium <= high <= 1")
+	}
+	return nil
+}
+
+// validateTrend checks baseline and anomaly settings
+func validateTrend(cfg *TrendConfig) error {
+	if cfg.RetentionDays < 0 {
+		return fmt.Errorf("analysis.severity.trend.retention_days cannot be negative")
+	}
+	if cfg.AnomalyThreshold < 0 || cfg.MinPercentChange < 0 || cfg.MinCount < 0 {
+		return fmt.Errorf("analysis.severity.trend: anomaly_threshold, min_percent_change and min_count cannot be negative")
+	}
+	if cfg.EWMAAlpha < 0 || cfg.EWMAAlpha > 1 {
+		return fmt.Errorf("analysis.severity.trend.ewma_alpha must be between 0 and 1")
+	}
+	return nil
+}
+
+// validateRedaction checks redaction detectors, policies and fields
+func validateRedaction(cfg *RedactionConfig) error {
+	for _, name := range cfg.Builtins {
+		if !redactionBuiltins[name] {
+			return fmt.Errorf("redaction.builtins: unknown detector %q", name)
+		}
+	}
+	for _, rule := range cfg.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("redaction.rules: name is required")
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("redaction.rules.%s: invalid regex: %w", rule.Name, err)
+		}
+		if rule.Policy != "" && !redactionPolicies[rule.Policy] {
+			return fmt.Errorf("redaction.rules.%s: unknown policy %q", rule.Name, rule.Policy)
+		}
+	}
+	for field, policy := range cfg.Fields {
+		if !redactionFields[field] {
+			return fmt.Errorf("redaction.fields: unsupported field %q", field)
+		}
+		if !redactionPolicies[policy] {
+			return fmt.Errorf("redaction.fields.%s: unknown policy %q", field, policy)
+		}
+	}
+	return nil
+}
+
+// validateMaskers checks built-in masker names and regex maskers
+func validateMaskers(maskers []MaskerConfig) error {
+	for i, m := range maskers {
+		if m.Builtin != "" {
+			if !maskerBuiltins[m.Builtin] {
+				return fmt.Errorf("normalization.maskers[%d]: unknown built-in masker %q", i, m.Builtin)
+			}
+			continue
+		}
+		if m.Name == "" || m.Pattern == "" {
+			return fmt.Errorf("normalization.maskers[%d]: builtin or name and pattern are required", i)
+		}
+		if _, err := regexp.Compile(m.Pattern); err != nil {
+			return fmt.Errorf("normalization.maskers.%s: invalid regex: %w", m.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateNormalization checks the grouping strategy, template miner and merge settings
+func validateNormalization(cfg *NormalizationConfig) error {
+	switch cfg.Strategy {
+	case "", "fingerprint", "drain":
+	default:
+		return fmt.Errorf("normalization.strategy: unknown strategy %q (expected fingerprint or drain)", cfg.Strategy)
+	}
+	if cfg.Drain.SimilarityThreshold < 0 || cfg.Drain.SimilarityThreshold > 1 {
+		return fmt.Errorf("normalization.drain.similarity_threshold must be between 0 and 1")
+	}
+	if cfg.Drain.Depth != 0 && cfg.Drain.Depth < 3 {
+		return fmt.Errorf("normalization.drain.depth must be at least 3")
+	}
+	if cfg.Drain.MaxChildren < 0 {
+		return fmt.Errorf("normalization.drain.max_children cannot be negative")
+	}
+	if cfg.Merge.JaccardThreshold < 0 || cfg.Merge.JaccardThreshold > 1 {
+		return fmt.Errorf("normalization.merge.jaccard_threshold must be between 0 and 1")
+	}
+	if cfg.Merge.EditSimilarityThreshold < 0 || cfg.Merge.EditSimilarityThreshold > 1 {
+		return fmt.Errorf("normalization.merge.edit_similarity_threshold must be between 0 and 1")
+	}
+	if cfg.MaskedValues.TopK < 0 {
+		return fmt.Errorf("normalization.masked_values.top_k cannot be negative")
+	}
+	switch cfg.GroupBy {
+	case "", "content_caller", "content", "caller":
+	default:
+		return fmt.Errorf("normalization.group_by: unknown mode %q (expected content_caller, content or caller)", cfg.GroupBy)
+	}
+	switch cfg.Caller.Keep {
+	case "", "full", "file", "package":
+	default:
+		return fmt.Errorf("normalization.caller.keep: unknown value %q (expected full, file or package)", cfg.Caller.Keep)
+	}
+	for _, split := range cfg.Fingerprints.Splits {
+		if split.Fingerprint == "" || split.Name == "" {
+			return fmt.Errorf("normalization.fingerprints.splits: fingerprint and name are required")
+		}
+		if _, err := regexp.Compile(split.Pattern); err != nil {
+			return fmt.Errorf("normalization.fingerprints.splits.%s: invalid regex: %w", split.Name, err)
+		}
+	}
+	for _, sep := range cfg.ErrorChain.Separators {
+		if strings.TrimSpace(sep) == "" {
+			return fmt.Errorf("normalization.error_chain.separators cannot contain blank separators")
+		}
+	}
+	return nil
+}
+
+// validateTimestamps checks timestamp sources and timezones
+func validateTimestamps(config *Config) error {
+	validSources := map[string]bool{"inner": true, "wrapper": true, "source": true}
+	for _, source := range config.Preprocessing.Timestamps.Order {
+		if !validSources[source] {
+			return fmt.Errorf("preprocessing.timestamps.order: unknown source %q", source)
+		}
+	}
+	if tz := config.Preprocessing.Timestamps.SourceTimezone; tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			return fmt.Errorf("preprocessing.timestamps.source_timezone: %w", err)
+		}
+	}
+	if tz := config.Output.DisplayTimezone; tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			return fmt.Errorf("output.display_timezone: %w", err)
+		}
+	}
 	return nil
 }
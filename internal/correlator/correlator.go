@@ -0,0 +1,220 @@
+package correlator
+
+import (
+	"sort"
+	"time"
+
+	"log-analyzer/internal/config"
+	"log-analyzer/internal/interfaces"
+	"log-analyzer/pkg/models"
+)
+
+// Correlation defaults, used for settings left unset in the configuration
+const (
+	defaultMinSharedTraces = 3
+	defaultMinOrderRatio   = 0.6
+)
+
+// Correlator links error groups that share trace IDs and derives cascade chains:
+// an origin group followed, within the same traces, by errors in downstream groups.
+type Correlator struct {
+	minSharedTraces    int
+	minOrderRatio      float64
+	includeSameService bool
+}
+
+// NewCorrelator creates a correlator from configuration
+func NewCorrelator(cfg config.CorrelationConfig) *Correlator {
+	minShared := cfg.MinSharedTraces
+	if minShared <= 0 {
+		minShared = defaultMinSharedTraces
+	}
+	minRatio := cfg.MinOrderRatio
+	if minRatio <= 0 {
+		minRatio = defaultMinOrderRatio
+	}
+
+	return &Correlator{
+		minSharedTraces:    minShared,
+		minOrderRatio:      minRatio,
+		includeSameService: cfg.IncludeSameService,
+	}
+}
+
+// pairStats accumulates the traces shared by two groups (a < b by index)
+type pairStats struct {
+	shared  int
+	aFirst  int
+	bFirst  int
+	aToBLag []time.Duration // b minus a, per shared trace
+}
+
+// Correlate returns directed links between groups and the cascade chains they form
+func (c *Correlator) Correlate(groups []models.ErrorGroup) ([]interfaces.GroupCorrelation, []interfaces.CascadeChain) {
+	// trace -> groups (by index) in which it appears
+	traceGroups := make(map[string][]int)
+	for i, group := range groups {
+		for trace := range group.TraceFirstSeen {
+			traceGroups[trace] = append(traceGroups[trace], i)
+		}
+	}
+
+	pairs := make(map[[2]int]*pairStats)
+	for trace, members := range traceGroups {
+		for x := 0; x < len(members); x++ {
+			for y := x + 1; y < len(members); y++ {
+				a, b := members[x], members[y]
+				if a > b {
+					a, b = b, a
+				}
+				if !c.includeSameService && groups[a].ServiceName == groups[b].ServiceName {
+					continue
+				}
+
+				key := [2]int{a, b}
+				stats, ok := pairs[key]
+				if !ok {
+					stats = &pairStats{}
+					pairs[key] = stats
+				}
+
+				lag := groups[b].TraceFirstSeen[trace].Sub(groups[a].TraceFirstSeen[trace])
+				stats.shared++
+				stats.aToBLag = append(stats.aToBLag, lag)
+				switch {
+				case lag > 0:
+					stats.aFirst++
+				case lag < 0:
+					stats.bFirst++
+				}
+			}
+		}
+	}
+
+	var correlations []interfaces.GroupCorrelation
+	for key, stats := range pairs {
+		if stats.shared < c.minSharedTraces {
+			continue
+		}
+
+		up, down, first, lags := key[0], key[1], stats.aFirst, stats.aToBLag
+		if stats.bFirst > stats.aFirst {
+			up, down, first = key[1], key[0], stats.bFirst
+			lags = negate(stats.aToBLag)
+		}
+
+		ratio := float64(first) / float64(stats.shared)
+		if ratio < c.minOrderRatio {
+			continue
+		}
+
+		correlations = append(correlations, interfaces.GroupCorrelation{
+			UpstreamFingerprint:   groups[up].Fingerprint,
+			UpstreamService:       groups[up].ServiceName,
+			DownstreamFingerprint: groups[down].Fingerprint,
+			DownstreamService:     groups[down].ServiceName,
+			SharedTraces:          stats.shared,
+			UpstreamFirstRatio:    ratio,
+			MedianLag:             median(lags),
+		})
+	}
+
+	sort.Slice(correlations, func(i, j int) bool {
+		if correlations[i].SharedTraces != correlations[j].SharedTraces {
+			return correlations[i].SharedTraces > correlations[j].SharedTraces
+		}
+		return correlations[i].UpstreamFingerprint+correlations[i].DownstreamFingerprint <
+			correlations[j].UpstreamFingerprint+correlations[j].DownstreamFingerprint
+	})
+
+	return correlations, buildCascades(groups, correlations)
+}
+
+// buildCascades walks links from origin groups (no upstream) to their downstream effects
+func buildCascades(groups []models.ErrorGroup, correlations []interfaces.GroupCorrelation) []interfaces.CascadeChain {
+	byFingerprint := make(map[string]models.ErrorGroup, len(groups))
+	for _, group := range groups {
+		byFingerprint[group.Fingerprint] = group
+	}
+
+	downstream := make(map[string][]interfaces.GroupCorrelation)
+	hasUpstream := make(map[string]bool)
+	for _, link := range correlations {
+		downstream[link.UpstreamFingerprint] = append(downstream[link.UpstreamFingerprint], link)
+		hasUpstream[link.DownstreamFingerprint] = true
+	}
+
+	var chains []interfaces.CascadeChain
+	for _, link := range correlations {
+		origin := link.UpstreamFingerprint
+		if hasUpstream[origin] || containsOrigin(chains, origin) {
+			continue
+		}
+
+		chain := interfaces.CascadeChain{
+			OriginFingerprint: origin,
+			OriginService:     byFingerprint[origin].ServiceName,
+			OriginContent:     byFingerprint[origin].NormalizedContent,
+		}
+
+		// Breadth-first so each effect gets its shortest depth; visited guards against cycles
+		visited := map[string]bool{origin: true}
+		queue := []string{origin}
+		for depth := 1; len(queue) > 0; depth++ {
+			var next []string
+			for _, fp := range queue {
+				for _, edge := range downstream[fp] {
+					if visited[edge.DownstreamFingerprint] {
+						continue
+					}
+					visited[edge.DownstreamFingerprint] = true
+					next = append(next, edge.DownstreamFingerprint)
+
+					effect := byFingerprint[edge.DownstreamFingerprint]
+					chain.Effects = append(chain.Effects, interfaces.CascadeEffect{
+						Fingerprint:  edge.DownstreamFingerprint,
+						Service:      effect.ServiceName,
+						Content:      effect.NormalizedContent,
+						Depth:        depth,
+						SharedTraces: edge.SharedTraces,
+						MedianLag:    edge.MedianLag,
+					})
+				}
+			}
+			queue = next
+		}
+
+		chains = append(chains, chain)
+	}
+
+	return chains
+}
+
+// containsOrigin reports whether a chain for origin was already built
+func containsOrigin(chains []interfaces.CascadeChain, origin string) bool {
+	for _, chain := range chains {
+		if chain.OriginFingerprint == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// negate returns the lags with their sign flipped
+func negate(lags []time.Duration) []time.Duration {
+	result := make([]time.Duration, len(lags))
+	for i, lag := range lags {
+		result[i] = -lag
+	}
+	return result
+}
+
+// median returns the median of the durations
+func median(values []time.Duration) time.Duration {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2]
+}
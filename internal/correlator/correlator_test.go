@@ -0,0 +1,70 @@
+package correlator
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"log-analyzer/internal/config"
+	"log-analyzer/pkg/models"
+)
+
+func group(fp, service string, traces map[string]time.Time) models.ErrorGroup {
+	return models.ErrorGroup{Fingerprint: fp, ServiceName: service, NormalizedContent: fp + " error", TraceFirstSeen: traces}
+}
+
+func TestCorrelateBuildsCascadeChains(t *testing.T) {
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	wallet := map[string]time.Time{}
+	api := map[string]time.Time{}
+	rpc := map[string]time.Time{}
+	noise := map[string]time.Time{}
+	for i := 0; i < 5; i++ {
+		trace := fmt.Sprintf("trace-%d", i)
+		start := base.Add(time.Duration(i) * time.Minute)
+		wallet[trace] = start
+		api[trace] = start.Add(100 * time.Millisecond)
+		rpc[trace] = start.Add(300 * time.Millisecond)
+	}
+	// Only two shared traces: below the default threshold
+	noise["trace-0"] = base.Add(time.Second)
+	noise["trace-1"] = base.Add(time.Second)
+
+	groups := []models.ErrorGroup{
+		group("api", "pp-slot-api", api),
+		group("rpc", "pp-slot-rpc", rpc),
+		group("wallet", "wallet-api", wallet),
+		group("noise", "other", noise),
+		group("api2", "pp-slot-api", api), // same service as api: not linked by default
+	}
+
+	correlations, cascades := NewCorrelator(config.CorrelationConfig{}).Correlate(groups)
+
+	for _, c := range correlations {
+		if c.UpstreamFingerprint == "noise" || c.DownstreamFingerprint == "noise" {
+			t.Errorf("noise should not be correlated: %+v", c)
+		}
+		if c.UpstreamService == c.DownstreamService {
+			t.Errorf("same-service link found: %+v", c)
+		}
+	}
+
+	if len(cascades) != 1 {
+		t.Fatalf("expected 1 cascade chain, got %d: %+v", len(cascades), cascades)
+	}
+	chain := cascades[0]
+	if chain.OriginFingerprint != "wallet" {
+		t.Errorf("origin = %s, want wallet", chain.OriginFingerprint)
+	}
+
+	effects := map[string]int{}
+	for _, e := range chain.Effects {
+		effects[e.Fingerprint] = e.Depth
+		if e.Fingerprint == "api" && e.MedianLag != 100*time.Millisecond {
+			t.Errorf("api lag = %s, want 100ms", e.MedianLag)
+		}
+	}
+	if effects["api"] != 1 || effects["rpc"] != 1 || effects["api2"] != 1 {
+		t.Errorf("unexpected effects: %+v", chain.Effects)
+	}
+}
@@ -0,0 +1,69 @@
+package deploy
+
+import (
+	"sort"
+	"time"
+
+	"log-analyzer/pkg/models"
+)
+
+// Annotate sets Deploy on groups whose onset falls within window after a deploy of the same service.
+// Onsets are the group's episode onsets and its first occurrence; a first occurrence within window
+// of dataStart is skipped, since the group may have existed before the data begins.
+// events must be sorted by time. It returns the number of annotated groups.
+func Annotate(groups []models.ErrorGroup, events []models.DeployEvent, window time.Duration, dataStart time.Time) int {
+	byService := make(map[string][]models.DeployEvent)
+	for _, event := range events {
+		byService[event.Service] = append(byService[event.Service], event)
+	}
+
+	annotated := 0
+	for i := range groups {
+		group := &groups[i]
+		group.Deploy = nil
+		serviceEvents := byService[group.ServiceName]
+		if len(serviceEvents) == 0 {
+			continue
+		}
+
+		for _, onset := range onsets(*group, window, dataStart) {
+			if event, ok := latestBefore(serviceEvents, onset, window); ok {
+				group.Deploy = &models.DeployMatch{Event: event, Onset: onset, Lag: onset.Sub(event.Time)}
+				annotated++
+				break
+			}
+		}
+	}
+	return annotated
+}
+
+// onsets returns the times a group started, earliest first
+func onsets(group models.ErrorGroup, window time.Duration, dataStart time.Time) []time.Time {
+	var times []time.Time
+	if !group.FirstSeen.IsZero() && group.FirstSeen.Sub(dataStart) >= window {
+		times = append(times, group.FirstSeen)
+	}
+	for _, episode := range group.Episodes {
+		times = append(times, episode.Onset)
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+	return times
+}
+
+// latestBefore returns the last event at or before onset and no more than window earlier
+func latestBefore(events []models.DeployEvent, onset time.Time, window time.Duration) (models.DeployEvent, bool) {
+	var match models.DeployEvent
+	found := false
+	for _, event := range events {
+		if event.Time.After(onset) {
+			break
+		}
+		if onset.Sub(event.Time) <= window {
+			match = event
+			found = true
+		}
+	}
+	return match, found
+}
@@ -0,0 +1,140 @@
+package deploy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"log-analyzer/internal/preprocessor"
+	"log-analyzer/pkg/models"
+)
+
+func TestLoadFileFormats(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "deploys.yaml")
+	ndjsonPath := filepath.Join(dir, "deploys.ndjson")
+	os.WriteFile(yamlPath, []byte(`events:
+  - service: lc-jade-prod_PP_Slot_API
+    version: v1.4.2
+    time: 2025-03-03T14:03:00Z
+`), 0644)
+	os.WriteFile(ndjsonPath, []byte(`{"service":"pp-slot-math","version":"v2","time":"2025-03-03T10:00:00Z"}
+
+{"service":"pp-slot-api","version":"v1.4.1","time":"2025-03-03T09:00:00Z"}
+`), 0644)
+
+	store := NewStore(filepath.Join(dir, "store", "events.ndjson"), preprocessor.NewServiceExtractor())
+	if err := store.Append(models.DeployEvent{Service: "pp-slot-api", Version: "v1.4.3", Time: time.Date(2025, 3, 3, 16, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatalf("Append returned error: %v", err)
+	}
+	if err := store.Append(models.DeployEvent{Version: "v0"}); err == nil {
+		t.Error("Append accepted an event without service")
+	}
+
+	events, err := LoadAll(store, []string{yamlPath, ndjsonPath})
+	if err != nil {
+		t.Fatalf("LoadAll returned error: %v", err)
+	}
+	var versions []string
+	for _, event := range events {
+		versions = append(versions, event.Version)
+	}
+	if got := strings.Join(versions, ","); got != "v1.4.1,v2,v1.4.2,v1.4.3" {
+		t.Errorf("events = %s, want sorted by time", got)
+	}
+	// Services are normalized like the service names of parsed logs
+	if events[2].Service != "pp-slot-api" {
+		t.Errorf("service = %q, want pp-slot-api", events[2].Service)
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	dataStart := time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)
+	deployTime := dataStart.Add(2*time.Hour + 3*time.Minute)
+	events := []models.DeployEvent{
+		{Service: "pp-slot-api", Version: "v1.4.1", Time: dataStart.Add(-time.Hour)},
+		{Service: "pp-slot-api", Version: "v1.4.2", Time: deployTime},
+		{Service: "pp-slot-math", Version: "v2", Time: deployTime},
+	}
+	groups := []models.ErrorGroup{
+		// New group right after the deploy
+		{Fingerprint: "v2:new", ServiceName: "pp-slot-api", FirstSeen: deployTime.Add(4 * time.Minute)},
+		// Chronic group whose rate shifted after the deploy
+		{Fingerprint: "v2:shift", ServiceName: "pp-slot-api", FirstSeen: dataStart,
+			Episodes: []models.Episode{{Onset: deployTime.Add(10 * time.Minute)}}},
+		// Chronic group present from the start, no shift
+		{Fingerprint: "v2:chronic", ServiceName: "pp-slot-api", FirstSeen: dataStart},
+		// Started too long after the deploy
+		{Fingerprint: "v2:late", ServiceName: "pp-slot-api", FirstSeen: deployTime.Add(45 * time.Minute)},
+		// Another service's deploy does not count
+		{Fingerprint: "v2:other", ServiceName: "pp-slot-admin", FirstSeen: deployTime.Add(time.Minute)},
+	}
+
+	if annotated := Annotate(groups, events, 30*time.Minute, dataStart); annotated != 2 {
+		t.Errorf("annotated %d groups, want 2", annotated)
+	}
+	if match := groups[0].Deploy; match == nil || match.Event.Version != "v1.4.2" || match.Lag != 4*time.Minute {
+		t.Errorf("new group deploy = %+v", match)
+	}
+	if match := groups[1].Deploy; match == nil || match.Lag != 10*time.Minute {
+		t.Errorf("shifted group deploy = %+v", match)
+	}
+	for _, group := range groups[2:] {
+		if group.Deploy != nil {
+			t.Errorf("%s annotated with %+v", group.Fingerprint, group.Deploy)
+		}
+	}
+}
+
+func TestWebhookHandler(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "events.ndjson"), preprocessor.NewServiceExtractor())
+	handler := NewWebhookHandler(store, "secret")
+
+	post := func(body, token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := post(`{"service":"pp-slot-api","version":"v1"}`, ""); code != http.StatusUnauthorized {
+		t.Errorf("missing token: status %d", code)
+	}
+	if code := post(`{"version":"v1"}`, "secret"); code != http.StatusBadRequest {
+		t.Errorf("missing service: status %d", code)
+	}
+	if code := post(`[{"service":"pp-slot-api","version":"v1","time":"2025-03-03T14:03:00Z"},{"service":"pp-slot-math"}]`, "secret"); code != http.StatusAccepted {
+		t.Errorf("valid events: status %d", code)
+	}
+
+	events, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(events) != 2 || events[1].Time.IsZero() {
+		t.Errorf("stored events = %+v, want 2 with times", events)
+	}
+}
+
+func TestIsLoopbackAddr(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1:8090": true,
+		"localhost:8090": true,
+		"[::1]:8090":     true,
+		":8090":          false,
+		"0.0.0.0:8090":   false,
+		"10.0.0.5:8090":  false,
+	}
+	for addr, expected := range tests {
+		if got := IsLoopbackAddr(addr); got != expected {
+			t.Errorf("IsLoopbackAddr(%q) = %v, want %v", addr, got, expected)
+		}
+	}
+}
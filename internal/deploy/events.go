@@ -0,0 +1,184 @@
+package deploy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+
+	"log-analyzer/internal/preprocessor"
+	"log-analyzer/pkg/models"
+)
+
+// Store is the append-only NDJSON file of deploy events written by the annotate command and the webhook.
+// Service names are normalized with the service extraction rules so they match parsed logs.
+type Store struct {
+	path     string
+	services *preprocessor.ServiceExtractor
+	mu       sync.Mutex
+}
+
+// NewStore creates a store persisted at path, normalizing services with the given extractor
+func NewStore(path string, services *preprocessor.ServiceExtractor) *Store {
+	return &Store{path: path, services: services}
+}
+
+// Append validates and normalizes events and appends them to the store, one JSON object per line
+func (s *Store) Append(events ...models.DeployEvent) error {
+	if err := normalizeEvents(events, s.services); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal deploy event: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create deploy event directory: %w", err)
+	}
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open deploy events: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write deploy events: %w", err)
+	}
+	return nil
+}
+
+// Load reads the stored events; a missing store has no events
+func (s *Store) Load() ([]models.DeployEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events, err := LoadFile(s.path, s.services)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return events, err
+}
+
+// Validate checks that an event names a service and a time
+func Validate(event models.DeployEvent) error {
+	if event.Service == "" {
+		return fmt.Errorf("deploy event: service is required")
+	}
+	if event.Time.IsZero() {
+		return fmt.Errorf("deploy event for %s: time is required", event.Service)
+	}
+	return nil
+}
+
+// LoadFile reads events from a YAML file (.yaml/.yml, a list or an "events" list) or an NDJSON file,
+// normalizing their services with the given extractor
+func LoadFile(path string, services *preprocessor.ServiceExtractor) ([]models.DeployEvent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []models.DeployEvent
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		events, err = parseYAML(data)
+	default:
+		events, err = parseNDJSON(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse deploy events %s: %w", path, err)
+	}
+
+	if err := normalizeEvents(events, services); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return events, nil
+}
+
+// normalizeEvents validates events and rewrites their services to the names parsed logs get
+// (environment prefix stripped, lowercased, "_" → "-"), so they match group service names
+func normalizeEvents(events []models.DeployEvent, services *preprocessor.ServiceExtractor) error {
+	for i := range events {
+		if err := Validate(events[i]); err != nil {
+			return err
+		}
+		service := services.NormalizeServiceName(events[i].Service)
+		if service == "" {
+			return fmt.Errorf("deploy event: %q is not a valid service name", events[i].Service)
+		}
+		events[i].Service = service
+	}
+	return nil
+}
+
+// LoadAll reads the store and the extra event files, sorted by time
+func LoadAll(store *Store, files []string) ([]models.DeployEvent, error) {
+	events, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range files {
+		fileEvents, err := LoadFile(path, store.services)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, fileEvents...)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events, nil
+}
+
+// parseYAML accepts a top-level list of events or a document with an "events" list
+func parseYAML(data []byte) ([]models.DeployEvent, error) {
+	var events []models.DeployEvent
+	if err := yaml.Unmarshal(data, &events); err == nil {
+		return events, nil
+	}
+
+	var doc struct {
+		Events []models.DeployEvent `yaml:"events"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc.Events, nil
+}
+
+// parseNDJSON reads one JSON event per line, skipping blank lines
+func parseNDJSON(data []byte) ([]models.DeployEvent, error) {
+	var events []models.DeployEvent
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var event models.DeployEvent
+		if err := json.Unmarshal([]byte(text), &event); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
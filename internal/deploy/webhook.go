@@ -0,0 +1,109 @@
+package deploy
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"log-analyzer/pkg/models"
+)
+
+// maxWebhookBody limits the size of a webhook request
+const maxWebhookBody = 1 << 20
+
+// WebhookHandler receives deploy events over HTTP and appends them to the store.
+// It accepts POST requests with one JSON event or a JSON array of events.
+type WebhookHandler struct {
+	store *Store
+	token string
+}
+
+// NewWebhookHandler creates a handler; a non-empty token is required as a bearer token
+func NewWebhookHandler(store *Store, token string) *WebhookHandler {
+	return &WebhookHandler{store: store, token: token}
+}
+
+// ServeHTTP handles a webhook request
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.token != "" {
+		expected := []byte("Bearer " + h.token)
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody+1))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxWebhookBody {
+		http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	events, err := decodeEvents(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for i := range events {
+		if events[i].Time.IsZero() {
+			events[i].Time = time.Now().UTC()
+		}
+	}
+	if err := normalizeEvents(events, h.store.services); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.Append(events...); err != nil {
+		http.Error(w, "failed to store events", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, "stored %d event(s)\n", len(events))
+}
+
+// IsLoopbackAddr reports whether a listen address only accepts local connections
+// (an empty host listens on all interfaces)
+func IsLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// decodeEvents parses one JSON event or a JSON array of events
+func decodeEvents(body []byte) ([]models.DeployEvent, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var events []models.DeployEvent
+		if err := json.Unmarshal(body, &events); err != nil {
+			return nil, fmt.Errorf("invalid event list: %w", err)
+		}
+		return events, nil
+	}
+
+	var event models.DeployEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("invalid event: %w", err)
+	}
+	return []models.DeployEvent{event}, nil
+}
@@ -28,15 +28,38 @@ func NewFetcher(cfg *config.Config) *Fetcher {
 
 // FetchWithTimeWindows fetches logs with time window splitting to avoid 500-hit limit
 func (f *Fetcher) FetchWithTimeWindows(timeRangeStr string) ([]models.RawLog, error) {
+	var allLogs []models.RawLog
+	err := f.fetchWindows(timeRangeStr, func(logs []models.RawLog) {
+		allLogs = append(allLogs, logs...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allLogs, nil
+}
+
+// StreamWithTimeWindows fetches logs like FetchWithTimeWindows but sends them to out
+// window by window instead of collecting them; out is closed when fetching ends
+func (f *Fetcher) StreamWithTimeWindows(timeRangeStr string, out chan<- models.RawLog) error {
+	defer close(out)
+	return f.fetchWindows(timeRangeStr, func(logs []models.RawLog) {
+		for _, log := range logs {
+			out <- log
+		}
+	})
+}
+
+// fetchWindows fetches each time window of the range and hands its logs to emit
+func (f *Fetcher) fetchWindows(timeRangeStr string, emit func([]models.RawLog)) error {
 	// Parse time range and window size
 	duration, err := time.ParseDuration(timeRangeStr)
 	if err != nil {
-		return nil, fmt.Errorf("invalid time range: %w", err)
+		return fmt.Errorf("invalid time range: %w", err)
 	}
 
 	windowDuration, err := time.ParseDuration("30m") // Fixed window size
 	if err != nil {
-		return nil, fmt.Errorf("invalid window size: %w", err)
+		return fmt.Errorf("invalid window size: %w", err)
 	}
 
 	endTime := time.Now()
@@ -50,8 +73,6 @@ func (f *Fetcher) FetchWithTimeWindows(timeRangeStr string) ([]models.RawLog, er
 	fmt.Printf("   📊 跨 %d 個時間窗口獲取日誌（每個 %.0f 分鐘）\n", numWindows, windowDuration.Minutes())
 	fmt.Println()
 
-	var allLogs []models.RawLog
-
 	// Fetch data for each window
 	for i := 0; i < numWindows; i++ {
 		windowEnd := endTime.Add(-time.Duration(i) * windowDuration)
@@ -67,11 +88,11 @@ func (f *Fetcher) FetchWithTimeWindows(timeRangeStr string) ([]models.RawLog, er
 		}
 
 		fmt.Printf("      ✅ 共 %d 條日誌\n", len(logs))
-		allLogs = append(allLogs, logs...)
+		emit(logs)
 	}
 
 	fmt.Println()
-	return allLogs, nil
+	return nil
 }
 
 // fetchFromWindow fetches logs from a specific time window
@@ -87,47 +108,9 @@ func (f *Fetcher) fetchFromWindow(startTime, endTime time.Time) ([]models.RawLog
 	for _, index := range f.config.OpenSearch.Indices {
 		index = strings.TrimSpace(index)
 
-		body := map[string]interface{}{
-			"params": map[string]interface{}{
-				"index": index,
-				"body":  query,
-			},
-		}
-
-		jsonBody, err := json.Marshal(body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal request: %w", err)
-		}
-
-		url := fmt.Sprintf("%s/internal/search/opensearch-with-long-numerals", f.config.OpenSearch.URL)
-		req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
-		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
-		}
-
-		// Set headers
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Basic "+basicAuth(f.config.OpenSearch.Username, f.config.OpenSearch.Password))
-		req.Header.Set("osd-xsrf", "osd-fetch")
-		req.Header.Set("osd-version", "3.0.0")
-
-		resp, err := client.Do(req)
+		response, err := f.search(client, index, query)
 		if err != nil {
-			fmt.Printf("         [%s] ❌ 連接失敗：%v\n", index, err)
-			continue
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			fmt.Printf("         [%s] ❌ API 返回 %d：%s\n", index, resp.StatusCode, string(bodyBytes))
-			continue
-		}
-
-		// Parse response
-		var response map[string]interface{}
-		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-			fmt.Printf("         [%s] ❌ 解析響應失敗：%v\n", index, err)
+			fmt.Printf("         [%s] ❌ %v\n", index, err)
 			continue
 		}
 
@@ -175,6 +158,51 @@ func (f *Fetcher) fetchFromWindow(startTime, endTime time.Time) ([]models.RawLog
 	return allLogs, nil
 }
 
+// search posts a query for one index through the Dashboards search API and decodes the response
+func (f *Fetcher) search(client *http.Client, index string, query map[string]interface{}) (map[string]interface{}, error) {
+	body := map[string]interface{}{
+		"params": map[string]interface{}{
+			"index": index,
+			"body":  query,
+		},
+	}
+
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/internal/search/opensearch-with-long-numerals", f.config.OpenSearch.URL)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set headers
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Basic "+basicAuth(f.config.OpenSearch.Username, f.config.OpenSearch.Password))
+	req.Header.Set("osd-xsrf", "osd-fetch")
+	req.Header.Set("osd-version", "3.0.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("連接失敗：%w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API 返回 %d：%s", resp.StatusCode, string(bodyBytes))
+	}
+
+	// Parse response
+	var response map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("解析響應失敗：%w", err)
+	}
+	return response, nil
+}
+
 // buildDashboardsQuery builds a query for specific time window
 func (f *Fetcher) buildDashboardsQuery(startTime, endTime time.Time) map[string]interface{} {
 	return map[string]interface{}{
@@ -194,13 +222,7 @@ func (f *Fetcher) buildDashboardsQuery(startTime, endTime time.Time) map[string]
 			"bool": map[string]interface{}{
 				"must": []interface{}{},
 				"filter": []interface{}{
-					map[string]interface{}{
-						"multi_match": map[string]interface{}{
-							"type":    "phrase",
-							"query":   f.config.Query.Keyword,
-							"lenient": true,
-						},
-					},
+					f.keywordFilter(),
 					map[string]interface{}{
 						"range": map[string]interface{}{
 							"@timestamp": map[string]interface{}{
@@ -218,6 +240,17 @@ func (f *Fetcher) buildDashboardsQuery(startTime, endTime time.Time) map[string]
 	}
 }
 
+// keywordFilter matches the documents containing the configured error keyword
+func (f *Fetcher) keywordFilter() map[string]interface{} {
+	return map[string]interface{}{
+		"multi_match": map[string]interface{}{
+			"type":    "phrase",
+			"query":   f.config.Query.Keyword,
+			"lenient": true,
+		},
+	}
+}
+
 // basicAuth creates a basic auth header value
 func basicAuth(username, password string) string {
 	credentials := fmt.Sprintf("%s:%s", username, password)
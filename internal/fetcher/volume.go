@@ -0,0 +1,148 @@
+package fetcher
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"log-analyzer/pkg/models"
+)
+
+// maxVolumeServices bounds the services returned by the volume aggregation
+const maxVolumeServices = 500
+
+// FetchVolume counts the documents matching the volume query (all documents by default) per service,
+// in buckets of the configured interval, as the denominator of error ratios.
+// Aggregations are not limited by the hit size, so the whole range is one request per index.
+func (f *Fetcher) FetchVolume(startTime, endTime time.Time) (map[string][]models.TimeBucket, error) {
+	var filters []interface{}
+	if f.config.Volume.Query != "" {
+		filters = append(filters, map[string]interface{}{
+			"query_string": map[string]interface{}{
+				"query":   f.config.Volume.Query,
+				"lenient": true,
+			},
+		})
+	}
+	return f.fetchServiceHistogram(startTime, endTime, filters)
+}
+
+// FetchErrorVolume counts the documents matching the error keyword per service, in the same
+// buckets as FetchVolume. Unlike the log fetch it is not capped per window, so it is the exact
+// numerator of error ratios.
+func (f *Fetcher) FetchErrorVolume(startTime, endTime time.Time) (map[string][]models.TimeBucket, error) {
+	return f.fetchServiceHistogram(startTime, endTime, []interface{}{f.keywordFilter()})
+}
+
+// fetchServiceHistogram counts the documents matching filters per service and interval bucket
+func (f *Fetcher) fetchServiceHistogram(startTime, endTime time.Time, filters []interface{}) (map[string][]models.TimeBucket, error) {
+	query := f.buildVolumeQuery(startTime, endTime, filters)
+	client := &http.Client{Timeout: f.config.Query.Timeout}
+
+	counts := make(map[string]map[time.Time]int)
+	for _, index := range f.config.OpenSearch.Indices {
+		index = strings.TrimSpace(index)
+
+		response, err := f.search(client, index, query)
+		if err != nil {
+			return nil, fmt.Errorf("volume query on %s failed: %w", index, err)
+		}
+		if err := collectVolume(response, counts); err != nil {
+			return nil, fmt.Errorf("volume query on %s: %w", index, err)
+		}
+	}
+
+	volume := make(map[string][]models.TimeBucket, len(counts))
+	for service, buckets := range counts {
+		series := make([]models.TimeBucket, 0, len(buckets))
+		for start, count := range buckets {
+			series = append(series, models.TimeBucket{Start: start, Count: count})
+		}
+		sort.Slice(series, func(i, j int) bool {
+			return series[i].Start.Before(series[j].Start)
+		})
+		volume[service] = series
+	}
+	return volume, nil
+}
+
+// buildVolumeQuery builds a size-0 query with a per-service date histogram over the documents matching filters
+func (f *Fetcher) buildVolumeQuery(startTime, endTime time.Time, extra []interface{}) map[string]interface{} {
+	filters := []interface{}{
+		map[string]interface{}{
+			"range": map[string]interface{}{
+				"@timestamp": map[string]interface{}{
+					"gte":    startTime.Format(time.RFC3339),
+					"lt":     endTime.Format(time.RFC3339),
+					"format": "strict_date_optional_time",
+				},
+			},
+		},
+	}
+	filters = append(filters, extra...)
+
+	return map[string]interface{}{
+		"size": 0,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": filters,
+			},
+		},
+		"aggs": map[string]interface{}{
+			"services": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": f.config.Volume.ServiceField,
+					"size":  maxVolumeServices,
+				},
+				"aggs": map[string]interface{}{
+					"over_time": map[string]interface{}{
+						"date_histogram": map[string]interface{}{
+							"field":          "@timestamp",
+							"fixed_interval": fmt.Sprintf("%ds", int(f.config.Volume.Interval.Seconds())),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// collectVolume adds the service histogram buckets of a response to counts
+func collectVolume(response map[string]interface{}, counts map[string]map[time.Time]int) error {
+	rawResp, _ := response["rawResponse"].(map[string]interface{})
+	aggs, _ := rawResp["aggregations"].(map[string]interface{})
+	services, ok := aggs["services"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("response has no services aggregation")
+	}
+
+	serviceBuckets, _ := services["buckets"].([]interface{})
+	for _, sb := range serviceBuckets {
+		serviceBucket, ok := sb.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		service := fmt.Sprint(serviceBucket["key"])
+		overTime, _ := serviceBucket["over_time"].(map[string]interface{})
+		timeBuckets, _ := overTime["buckets"].([]interface{})
+
+		for _, tb := range timeBuckets {
+			timeBucket, ok := tb.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			key, ok1 := timeBucket["key"].(float64) // epoch milliseconds
+			docCount, ok2 := timeBucket["doc_count"].(float64)
+			if !ok1 || !ok2 || docCount == 0 {
+				continue
+			}
+			if counts[service] == nil {
+				counts[service] = make(map[time.Time]int)
+			}
+			counts[service][time.UnixMilli(int64(key)).UTC()] += int(docCount)
+		}
+	}
+	return nil
+}
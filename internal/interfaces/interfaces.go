@@ -25,6 +25,68 @@ type AggregationResult struct {
 	TotalErrorGroups int
 	TotalLogs        int
 	ProcessingTime   time.Duration
+	ParseFailures    *ParseFailureStats
+	ClockSkew        []PodClockSkew
+	RootCauses       []RootCauseGroup // populated when the root-cause view is enabled
+	Correlations     []GroupCorrelation
+	Cascades         []CascadeChain
+}
+
+// GroupCorrelation links two error groups that share trace IDs.
+// Upstream is the group that occurs first in most shared traces.
+type GroupCorrelation struct {
+	UpstreamFingerprint   string
+	UpstreamService       string
+	DownstreamFingerprint string
+	DownstreamService     string
+	SharedTraces          int
+	UpstreamFirstRatio    float64       // share of shared traces where upstream occurred first
+	MedianLag             time.Duration // downstream minus upstream first occurrence
+}
+
+// CascadeChain is an origin error group and the downstream groups it triggers
+type CascadeChain struct {
+	OriginFingerprint string
+	OriginService     string
+	OriginContent     string
+	Effects           []CascadeEffect
+}
+
+// CascadeEffect is a downstream error group in a cascade chain
+type CascadeEffect struct {
+	Fingerprint  string
+	Service      string
+	Content      string
+	Depth        int // 1 = directly after the origin
+	SharedTraces int
+	MedianLag    time.Duration
+}
+
+// RootCauseGroup gathers error groups sharing the same innermost cause across callers and services
+type RootCauseGroup struct {
+	RootCause    string
+	TotalCount   int
+	Services     []string
+	Callers      []string
+	Fingerprints []string
+}
+
+// ParseFailureStats summarizes raw logs rejected during preprocessing
+type ParseFailureStats struct {
+	TotalRawLogs   int
+	Failed         int
+	Reasons        map[string]int // reason -> count
+	DeadLetterPath string
+}
+
+// PodClockSkew summarizes the skew between inner (application) and outer (runtime/shipper) timestamps of a pod
+type PodClockSkew struct {
+	Pod        string
+	Node       string
+	Samples    int
+	MedianSkew time.Duration
+	MaxSkew    time.Duration // largest absolute skew, with sign
+	Flagged    bool
 }
 
 // ServiceStats contains statistics for a specific service
@@ -34,23 +96,36 @@ type ServiceStats struct {
 	TotalErrors       int
 	HighPriorityCount int
 	PeakDensity       float64
+	// DistinctCounts is the approximate number of distinct affected entities across the service's groups
+	DistinctCounts map[string]int
+	// Episodes are the rate shifts found in the service's merged time series
+	Episodes []models.Episode
+	// ErrorRatio and BurnRates relate the service's errors to its total volume (volume.enabled)
+	ErrorRatio *models.ErrorRatio
+	BurnRates  []models.BurnRate
 }
 
-// TimeStats contains time-based statistics
+// TimeStats contains time-based statistics, derived from every log (not only samples)
 type TimeStats struct {
-	HourlyDistribution map[int]int // hour -> count
-	PeakHour           int
+	HourlyDistribution map[int]int // hour of day (display timezone) -> count, across days
+	PeakHour           int         // busiest hour of day
 	PeakCount          int
 	AverageDensity     float64
 
+	// Timeline is the absolute-time histogram, one bucket per display-timezone hour, gaps included;
+	// buckets are stepped in absolute time, so convert to the display timezone only for labels
+	Timeline []models.TimeBucket
+	// DayOfWeekDistribution counts errors by weekday (display timezone)
+	DayOfWeekDistribution map[time.Weekday]int
+
 	// Time range information
 	EarliestLogTime time.Time     // 最早日誌時間
 	LatestLogTime   time.Time     // 最晚日誌時間
 	QueryDuration   time.Duration // 查詢時間範圍
 
-	// Window-based peak statistics (30-minute granularity)
-	PeakWindowStart time.Time // 峰值 30 分鐘窗口的起始時間
-	PeakWindowEnd   time.Time // 峰值 30 分鐘窗口的結束時間
+	// Sliding peak window (analysis.density.peak_window_minutes) over the whole range, with its actual date
+	PeakWindowStart time.Time // 峰值窗口的起始時間
+	PeakWindowEnd   time.Time // 峰值窗口的結束時間
 	PeakWindowCount int       // 峰值窗口的錯誤數
 }
 
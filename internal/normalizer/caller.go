@@ -0,0 +1,136 @@
+package normalizer
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"log-analyzer/internal/config"
+)
+
+// Grouping modes: which parts of a log identify its group (service is always included)
+const (
+	GroupByContentCaller = "content_caller"
+	GroupByContent       = "content"
+	GroupByCaller        = "caller"
+)
+
+// Caller detail levels kept after normalization
+const (
+	CallerKeepFull    = "full"
+	CallerKeepFile    = "file"
+	CallerKeepPackage = "package"
+)
+
+var (
+	// callerLineRegex matches a trailing line number, e.g. "logic/spin.go:213"
+	callerLineRegex = regexp.MustCompile(`:\d+$`)
+	// moduleVersionRegex matches Go module versions in paths, e.g. "@v1.2.3"
+	moduleVersionRegex = regexp.MustCompile(`@v[0-9][^/]*`)
+)
+
+// CallerNormalizer rewrites caller strings so code moves between deploys do not split groups
+type CallerNormalizer struct {
+	stripLines bool
+	keep       string
+	mappings   []callerMapping // longest prefix first
+}
+
+// callerMapping rewrites a path prefix
+type callerMapping struct {
+	prefix      string
+	replacement string
+}
+
+// NewCallerNormalizer creates a caller normalizer from configuration
+func NewCallerNormalizer(cfg config.CallerConfig) *CallerNormalizer {
+	keep := cfg.Keep
+	if keep == "" {
+		keep = CallerKeepFull
+	}
+
+	mappings := make([]callerMapping, 0, len(cfg.PathMappings))
+	for prefix, replacement := range cfg.PathMappings {
+		mappings = append(mappings, callerMapping{prefix: prefix, replacement: replacement})
+	}
+	sort.Slice(mappings, func(i, j int) bool {
+		if len(mappings[i].prefix) != len(mappings[j].prefix) {
+			return len(mappings[i].prefix) > len(mappings[j].prefix)
+		}
+		return mappings[i].prefix < mappings[j].prefix
+	})
+
+	return &CallerNormalizer{
+		stripLines: cfg.StripLineNumbers,
+		keep:       keep,
+		mappings:   mappings,
+	}
+}
+
+// Normalize returns the normalized caller.
+// Vendored paths ("…/vendor/x") and module versions ("@v1.2.3") are always reduced;
+// configured prefixes are mapped, then line numbers and path detail are dropped as configured.
+func (c *CallerNormalizer) Normalize(caller string) string {
+	if c == nil || caller == "" {
+		return caller
+	}
+
+	normalized := caller
+	if i := strings.LastIndex(normalized, "/vendor/"); i >= 0 {
+		normalized = normalized[i+len("/vendor/"):]
+	}
+	normalized = moduleVersionRegex.ReplaceAllString(normalized, "")
+
+	for _, m := range c.mappings {
+		if strings.HasPrefix(normalized, m.prefix) {
+			normalized = m.replacement + strings.TrimPrefix(normalized, m.prefix)
+			break
+		}
+	}
+
+	line := callerLineRegex.FindString(normalized)
+	file := strings.TrimSuffix(normalized, line)
+	if c.stripLines {
+		line = ""
+	}
+
+	switch c.keep {
+	case CallerKeepFile:
+		return path.Base(file) + line
+	case CallerKeepPackage:
+		// Line numbers are meaningless without the file
+		return path.Dir(file)
+	default:
+		return file + line
+	}
+}
+
+// describe summarizes the settings for the ruleset digest
+func (c *CallerNormalizer) describe() string {
+	if c == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(c.keep)
+	if c.stripLines {
+		sb.WriteString(" strip-lines")
+	}
+	for _, m := range c.mappings {
+		sb.WriteString(" " + m.prefix + "=" + m.replacement)
+	}
+	return sb.String()
+}
+
+// groupKey returns the content and caller that identify a group under the grouping mode
+func groupKey(normalizedContent, caller, groupBy string) (string, string) {
+	switch groupBy {
+	case GroupByContent:
+		return normalizedContent, ""
+	case GroupByCaller:
+		return "", caller
+	default:
+		return normalizedContent, caller
+	}
+}
@@ -0,0 +1,68 @@
+package normalizer
+
+import (
+	"testing"
+	"time"
+
+	"log-analyzer/internal/config"
+	"log-analyzer/pkg/models"
+)
+
+func TestCallerNormalizer(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    config.CallerConfig
+		caller string
+		want   string
+	}{
+		{"default keeps caller", config.CallerConfig{}, "logic/spin.go:213", "logic/spin.go:213"},
+		{"strip lines", config.CallerConfig{StripLineNumbers: true}, "logic/spin.go:213", "logic/spin.go"},
+		{"file only", config.CallerConfig{Keep: "file"}, "internal/logic/spin.go:213", "spin.go:213"},
+		{"package only", config.CallerConfig{Keep: "package"}, "internal/logic/spin.go:213", "internal/logic"},
+		{"vendored", config.CallerConfig{StripLineNumbers: true}, "app/vendor/github.com/redis/go-redis/v9/pool.go:88", "github.com/redis/go-redis/v9/pool.go"},
+		{"module cache and mapping", config.CallerConfig{PathMappings: map[string]string{"github.com/acme/slot/": ""}},
+			"github.com/acme/slot@v1.4.2/logic/spin.go:10", "logic/spin.go:10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewCallerNormalizer(tt.cfg).Normalize(tt.caller); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.caller, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupingModes(t *testing.T) {
+	logs := []models.ParsedLog{
+		{Timestamp: time.Now(), Content: "redis timeout", ServiceName: "svc", Caller: "logic/spin.go:213", Level: models.LevelError},
+		{Timestamp: time.Now(), Content: "redis timeout", ServiceName: "svc", Caller: "logic/spin.go:215", Level: models.LevelError},
+		{Timestamp: time.Now(), Content: "redis timeout", ServiceName: "svc", Caller: "logic/bet.go:40", Level: models.LevelError},
+		{Timestamp: time.Now(), Content: "wallet error", ServiceName: "svc", Caller: "logic/bet.go:40", Level: models.LevelError},
+	}
+
+	tests := []struct {
+		groupBy    string
+		stripLines bool
+		want       int
+	}{
+		{GroupByContentCaller, false, 4},
+		{GroupByContentCaller, true, 3},
+		{GroupByContent, false, 2},
+		{GroupByCaller, true, 2},
+	}
+
+	for _, tt := range tests {
+		cfg := DefaultNormalizationConfig()
+		cfg.GroupBy = tt.groupBy
+		cfg.Caller = NewCallerNormalizer(config.CallerConfig{StripLineNumbers: tt.stripLines})
+
+		groups, err := NewLogNormalizerWithConfig(cfg).Normalize(logs)
+		if err != nil {
+			t.Fatalf("Normalize returned error: %v", err)
+		}
+		if len(groups) != tt.want {
+			t.Errorf("group_by=%s strip=%v: got %d groups, want %d", tt.groupBy, tt.stripLines, len(groups), tt.want)
+		}
+	}
+}
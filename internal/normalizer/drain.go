@@ -0,0 +1,337 @@
+package normalizer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"log-analyzer/internal/config"
+	"log-analyzer/pkg/models"
+)
+
+// Drain defaults, used for settings left unset in the configuration
+const (
+	defaultDrainDepth               = 4
+	defaultDrainSimilarityThreshold = 0.4
+	defaultDrainMaxChildren         = 100
+
+	// drainWildcard marks a variable token in a learned template
+	drainWildcard = "<*>"
+	// drainStateVersion is bumped when the persisted state format changes
+	drainStateVersion = 1
+)
+
+// DrainNormalizer groups logs by templates learned online with a Drain-style parse tree.
+// Messages are masked as in LogNormalizer, then routed by service, token count and
+// leading tokens to a leaf whose clusters are compared by token similarity.
+type DrainNormalizer struct {
+	base        *LogNormalizer
+	config      NormalizationConfig
+	depth       int
+	threshold   float64
+	maxChildren int
+	statePath   string
+
+	roots    map[string]*drainNode // service name -> root
+	clusters []*drainCluster
+	nextID   int
+	loaded   bool
+}
+
+// drainNode is an inner node (keyed by token count, then by leading tokens) or a leaf
+type drainNode struct {
+	children map[string]*drainNode
+	clusters []*drainCluster
+}
+
+// drainCluster is a learned template
+type drainCluster struct {
+	ID       int      `json:"id"`
+	Service  string   `json:"service"`
+	Template []string `json:"template"`
+	Size     int      `json:"size"`
+	// Path is the tree route (token count, then leading-token keys) the cluster was inserted under
+	Path []string `json:"path"`
+}
+
+// key identifies a persisted cluster in fingerprints (the service is added by the fingerprint itself)
+func (c *drainCluster) key() string {
+	return fmt.Sprintf("drain:%d", c.ID)
+}
+
+// drainState is the persisted form of the learned templates
+type drainState struct {
+	Version  int             `json:"version"`
+	NextID   int             `json:"next_id"`
+	Clusters []*drainCluster `json:"clusters"`
+}
+
+// NewDrainNormalizer creates a template-mining normalizer.
+// When drainCfg.StatePath is set, templates are loaded from it on the first
+// Normalize call and saved back after each call.
+func NewDrainNormalizer(normConfig NormalizationConfig, drainCfg config.DrainConfig) *DrainNormalizer {
+	depth := drainCfg.Depth
+	if depth < 3 {
+		depth = defaultDrainDepth
+	}
+	threshold := drainCfg.SimilarityThreshold
+	if threshold <= 0 || threshold > 1 {
+		threshold = defaultDrainSimilarityThreshold
+	}
+	maxChildren := drainCfg.MaxChildren
+	if maxChildren < 2 {
+		maxChildren = defaultDrainMaxChildren
+	}
+
+	return &DrainNormalizer{
+		base:        NewLogNormalizerWithConfig(normConfig),
+		config:      normConfig,
+		depth:       depth,
+		threshold:   threshold,
+		maxChildren: maxChildren,
+		statePath:   drainCfg.StatePath,
+		roots:       make(map[string]*drainNode),
+		nextID:      1,
+	}
+}
+
+// Normalize assigns each log to a learned template and groups logs by template cluster and caller
+func (d *DrainNormalizer) Normalize(logs []models.ParsedLog) ([]models.ErrorGroup, error) {
+	if d.statePath != "" && !d.loaded {
+		if err := d.LoadState(d.statePath); err != nil {
+			return nil, err
+		}
+	}
+
+	// Learn templates first so every log is grouped under its cluster's final template
+	assigned := make([]*drainCluster, len(logs))
+	values := make([][]maskedValue, len(logs))
+	for i, log := range logs {
+		var content string
+		content, values[i] = d.base.normalizeContentWithValues(log.Content, log.ServiceName, d.config)
+		assigned[i] = d.learn(log.ServiceName, strings.Fields(content))
+	}
+
+	// With persisted state, fingerprints use the cluster ID, which stays fixed while the
+	// template generalizes. Without it IDs restart every run, so the template text is used.
+	groups := newGroupBuilder(d.config)
+	for i, log := range logs {
+		template := strings.Join(assigned[i].Template, " ")
+		key := template
+		if d.statePath != "" {
+			key = assigned[i].key()
+		}
+		fingerprint := d.base.fingerprintFor(key, log, d.config)
+		groups.add(fingerprint, template, values[i], log)
+	}
+
+	if d.statePath != "" {
+		if err := d.SaveState(d.statePath); err != nil {
+			return nil, err
+		}
+	}
+
+	return groups.build(), nil
+}
+
+// TemplateCount returns the number of learned templates
+func (d *DrainNormalizer) TemplateCount() int {
+	return len(d.clusters)
+}
+
+// learn matches tokens against the tree, updating the best cluster or creating a new one
+func (d *DrainNormalizer) learn(service string, tokens []string) *drainCluster {
+	path := d.routeFor(tokens)
+	leaf := d.leafAt(service, path)
+
+	if cluster := d.bestMatch(leaf.clusters, tokens); cluster != nil {
+		for i, token := range tokens {
+			if cluster.Template[i] != token {
+				cluster.Template[i] = drainWildcard
+			}
+		}
+		cluster.Size++
+		return cluster
+	}
+
+	cluster := &drainCluster{
+		ID:       d.nextID,
+		Service:  service,
+		Template: append([]string(nil), tokens...),
+		Size:     1,
+		Path:     path,
+	}
+	d.nextID++
+	d.addCluster(cluster)
+	return cluster
+}
+
+// addCluster registers a cluster and inserts it into the tree at its path
+func (d *DrainNormalizer) addCluster(cluster *drainCluster) {
+	if len(cluster.Path) == 0 {
+		cluster.Path = d.routeFor(cluster.Template)
+	}
+	leaf := d.leafAt(cluster.Service, cluster.Path)
+	leaf.clusters = append(leaf.clusters, cluster)
+	d.clusters = append(d.clusters, cluster)
+}
+
+// routeFor computes the tree route for tokens: token count, then up to depth-3 leading tokens
+// (the root and the token-count node count towards the depth, as in Drain).
+// Tokens containing digits route to the wildcard child.
+func (d *DrainNormalizer) routeFor(tokens []string) []string {
+	path := []string{strconv.Itoa(len(tokens))}
+
+	for i := 0; i < d.depth-3 && i < len(tokens); i++ {
+		key := tokens[i]
+		if hasDigit(key) {
+			key = drainWildcard
+		}
+		path = append(path, key)
+	}
+
+	return path
+}
+
+// leafAt walks (and grows) the service tree along path.
+// A new key is redirected to the wildcard child once a node is full.
+func (d *DrainNormalizer) leafAt(service string, path []string) *drainNode {
+	root, ok := d.roots[service]
+	if !ok {
+		root = newDrainNode()
+		d.roots[service] = root
+	}
+
+	node := root
+	for i, key := range path {
+		if _, exists := node.children[key]; !exists && i > 0 && key != drainWildcard && len(node.children) >= d.maxChildren-1 {
+			// Keep one slot for the wildcard child
+			key = drainWildcard
+			path[i] = key
+		}
+		node = node.child(key)
+	}
+
+	return node
+}
+
+// bestMatch returns the most similar cluster above the threshold.
+// Ties are broken by the number of wildcards, preferring the more general template.
+func (d *DrainNormalizer) bestMatch(clusters []*drainCluster, tokens []string) *drainCluster {
+	var best *drainCluster
+	bestSim, bestParams := -1.0, -1
+
+	for _, cluster := range clusters {
+		if len(cluster.Template) != len(tokens) {
+			continue
+		}
+
+		same, params := 0, 0
+		for i, token := range cluster.Template {
+			switch {
+			case token == drainWildcard:
+				params++
+			case token == tokens[i]:
+				same++
+			}
+		}
+
+		sim := 1.0
+		if len(tokens) > 0 {
+			sim = float64(same) / float64(len(tokens))
+		}
+		if sim > bestSim || (sim == bestSim && params > bestParams) {
+			best, bestSim, bestParams = cluster, sim, params
+		}
+	}
+
+	if best == nil || bestSim < d.threshold {
+		return nil
+	}
+	return best
+}
+
+// LoadState loads learned templates from path; a missing file starts an empty tree
+func (d *DrainNormalizer) LoadState(path string) error {
+	d.loaded = true
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read drain state: %w", err)
+	}
+
+	var state drainState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse drain state %s: %w", path, err)
+	}
+	if state.Version != drainStateVersion {
+		return fmt.Errorf("unsupported drain state version %d in %s", state.Version, path)
+	}
+
+	d.roots = make(map[string]*drainNode)
+	d.clusters = nil
+	for _, cluster := range state.Clusters {
+		d.addCluster(cluster)
+	}
+	d.nextID = state.NextID
+	if d.nextID < 1 {
+		d.nextID = len(d.clusters) + 1
+	}
+
+	return nil
+}
+
+// SaveState writes learned templates to path, replacing the file atomically
+func (d *DrainNormalizer) SaveState(path string) error {
+	state := drainState{
+		Version:  drainStateVersion,
+		NextID:   d.nextID,
+		Clusters: d.clusters,
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal drain state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create drain state directory: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write drain state: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace drain state: %w", err)
+	}
+
+	return nil
+}
+
+// newDrainNode creates an empty tree node
+func newDrainNode() *drainNode {
+	return &drainNode{children: make(map[string]*drainNode)}
+}
+
+// child returns the child for key, creating it if needed
+func (n *drainNode) child(key string) *drainNode {
+	c, ok := n.children[key]
+	if !ok {
+		c = newDrainNode()
+		n.children[key] = c
+	}
+	return c
+}
+
+// hasDigit reports whether s contains an ASCII digit
+func hasDigit(s string) bool {
+	return strings.ContainsAny(s, "0123456789")
+}
@@ -0,0 +1,109 @@
+package normalizer
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"log-analyzer/internal/config"
+	"log-analyzer/pkg/models"
+)
+
+func drainLogs(contents ...string) []models.ParsedLog {
+	logs := make([]models.ParsedLog, len(contents))
+	for i, content := range contents {
+		logs[i] = models.ParsedLog{
+			Timestamp:   time.Date(2025, 1, 1, 10, 0, i, 0, time.UTC),
+			Content:     content,
+			Level:       models.LevelError,
+			ServiceName: "pp-slot-api",
+			Caller:      "handler.go:42",
+		}
+	}
+	return logs
+}
+
+func TestDrainNormalizerLearnsTemplates(t *testing.T) {
+	d := NewDrainNormalizer(DefaultNormalizationConfig(), config.DrainConfig{})
+
+	groups, err := d.Normalize(drainLogs(
+		"user alice login failed from web",
+		"user bob login failed from app",
+		"user carol login failed from web",
+		"cache miss for key session",
+	))
+	if err != nil {
+		t.Fatalf("Normalize returned error: %v", err)
+	}
+
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d: %+v", len(groups), groups)
+	}
+	if got, want := groups[0].NormalizedContent, "user <*> login failed from <*>"; got != want {
+		t.Errorf("template = %q, want %q", got, want)
+	}
+	if groups[0].TotalCount != 3 {
+		t.Errorf("expected 3 logs in the login group, got %d", groups[0].TotalCount)
+	}
+}
+
+func TestDrainNormalizerPersistsState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drain.json")
+	cfg := config.DrainConfig{StatePath: path}
+
+	first := NewDrainNormalizer(DefaultNormalizationConfig(), cfg)
+	if _, err := first.Normalize(drainLogs("user alice login failed", "user bob login failed")); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+
+	second := NewDrainNormalizer(DefaultNormalizationConfig(), cfg)
+	groups, err := second.Normalize(drainLogs("user dave login failed"))
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+
+	if second.TemplateCount() != 1 {
+		t.Errorf("expected the persisted template to be reused, got %d templates", second.TemplateCount())
+	}
+	if got, want := groups[0].NormalizedContent, "user <*> login failed"; got != want {
+		t.Errorf("template = %q, want %q", got, want)
+	}
+}
+
+func TestDrainFingerprintStableAsTemplateGeneralizes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drain.json")
+	cfg := config.DrainConfig{StatePath: path}
+
+	first, err := NewDrainNormalizer(DefaultNormalizationConfig(), cfg).Normalize(drainLogs("user alice login failed"))
+	if err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+
+	second, err := NewDrainNormalizer(DefaultNormalizationConfig(), cfg).Normalize(drainLogs("user bob login failed"))
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+
+	if got, want := second[0].NormalizedContent, "user <*> login failed"; got != want {
+		t.Fatalf("template = %q, want %q", got, want)
+	}
+	if second[0].Fingerprint != first[0].Fingerprint {
+		t.Errorf("fingerprint changed from %s to %s when the template generalized", first[0].Fingerprint, second[0].Fingerprint)
+	}
+}
+
+func TestDrainFingerprintWithoutStateUsesTemplate(t *testing.T) {
+	first, err := NewDrainNormalizer(DefaultNormalizationConfig(), config.DrainConfig{}).Normalize(drainLogs("database connection refused by host"))
+	if err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	second, err := NewDrainNormalizer(DefaultNormalizationConfig(), config.DrainConfig{}).Normalize(drainLogs("wallet balance insufficient for spin request"))
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+
+	// Cluster IDs restart every run without state, so they must not decide the fingerprint
+	if first[0].Fingerprint == second[0].Fingerprint {
+		t.Errorf("unrelated templates share fingerprint %s", first[0].Fingerprint)
+	}
+}
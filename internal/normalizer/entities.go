@@ -0,0 +1,102 @@
+package normalizer
+
+import (
+	"regexp"
+
+	"log-analyzer/internal/config"
+	"log-analyzer/pkg/models"
+	"log-analyzer/pkg/sketch"
+)
+
+// Built-in entities counted for every group, from the log's metadata
+const (
+	EntityTrace = "trace"
+	EntityPod   = "pod"
+	EntityHost  = "host"
+)
+
+// EntityField extracts a configured entity (e.g. a player ID) from log content
+type EntityField struct {
+	Name    string
+	pattern *regexp.Regexp
+}
+
+// NewEntityFields compiles entity fields from configuration.
+// Invalid patterns are skipped; they are rejected by config validation.
+func NewEntityFields(specs []config.EntityConfig) []EntityField {
+	fields := make([]EntityField, 0, len(specs))
+	for _, spec := range specs {
+		pattern, err := regexp.Compile(spec.Pattern)
+		if err != nil {
+			continue
+		}
+		fields = append(fields, EntityField{Name: spec.Name, pattern: pattern})
+	}
+	return fields
+}
+
+// Extract returns the entity value in content (its first capture group, if any), or ""
+func (f EntityField) Extract(content string) string {
+	match := f.pattern.FindStringSubmatch(content)
+	switch {
+	case match == nil:
+		return ""
+	case len(match) > 1:
+		return match[1]
+	default:
+		return match[0]
+	}
+}
+
+// countEntities adds the log's entity values to the group's distinct-count sketches
+func countEntities(group *models.ErrorGroup, log models.ParsedLog, fields []EntityField) {
+	addEntity(group, EntityTrace, log.Trace)
+	addEntity(group, EntityPod, log.Pod)
+	addEntity(group, EntityHost, log.Node)
+	for _, field := range fields {
+		addEntity(group, field.Name, field.Extract(log.Content))
+	}
+}
+
+// addEntity records a non-empty entity value, creating its sketch if needed
+func addEntity(group *models.ErrorGroup, name, value string) {
+	if value == "" {
+		return
+	}
+	if group.EntitySketches == nil {
+		group.EntitySketches = make(map[string]*sketch.HyperLogLog)
+	}
+	s, ok := group.EntitySketches[name]
+	if !ok {
+		s = sketch.NewHyperLogLog(sketch.DefaultPrecision)
+		group.EntitySketches[name] = s
+	}
+	s.Add(value)
+}
+
+// mergeEntitySketches folds src sketches into dst, cloning sketches dst does not have yet
+func mergeEntitySketches(dst, src map[string]*sketch.HyperLogLog) map[string]*sketch.HyperLogLog {
+	for name, s := range src {
+		if dst == nil {
+			dst = make(map[string]*sketch.HyperLogLog, len(src))
+		}
+		if existing, ok := dst[name]; ok {
+			existing.Merge(s)
+		} else {
+			dst[name] = s.Clone()
+		}
+	}
+	return dst
+}
+
+// DistinctCounts returns the estimated distinct values per entity
+func DistinctCounts(sketches map[string]*sketch.HyperLogLog) map[string]int {
+	if len(sketches) == 0 {
+		return nil
+	}
+	counts := make(map[string]int, len(sketches))
+	for name, s := range sketches {
+		counts[name] = s.Count()
+	}
+	return counts
+}
@@ -0,0 +1,77 @@
+package normalizer
+
+import (
+	"fmt"
+	"testing"
+
+	"log-analyzer/internal/config"
+	"log-analyzer/pkg/models"
+)
+
+func TestDistinctEntityCounts(t *testing.T) {
+	cfg := DefaultNormalizationConfig()
+	cfg.Entities = NewEntityFields([]config.EntityConfig{
+		{Name: "player_id", Pattern: `player=(\d+)`},
+	})
+
+	// One player retrying 100 times on one pod, and 20 other players once each
+	var logs []models.ParsedLog
+	for i := 0; i < 100; i++ {
+		logs = append(logs, models.ParsedLog{
+			Content:     "bet failed player=1001",
+			Trace:       fmt.Sprintf("trace-%d", i%10),
+			Pod:         "pod-a",
+			ServiceName: "pp-slot-api",
+		})
+	}
+	for i := 0; i < 20; i++ {
+		logs = append(logs, models.ParsedLog{
+			Content:     fmt.Sprintf("bet failed player=%d", 2000+i),
+			Pod:         "pod-b",
+			ServiceName: "pp-slot-api",
+		})
+	}
+
+	groups, err := NewLogNormalizerWithConfig(cfg).Normalize(logs)
+	if err != nil {
+		t.Fatalf("Normalize returned error: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("expected 1 group, got %d", len(groups))
+	}
+
+	counts := groups[0].DistinctCounts
+	want := map[string]int{EntityTrace: 10, EntityPod: 2, "player_id": 21}
+	for name, n := range want {
+		if counts[name] != n {
+			t.Errorf("%s: expected %d distinct, got %d", name, n, counts[name])
+		}
+	}
+	if _, ok := counts[EntityHost]; ok {
+		t.Errorf("expected no host count without node metadata, got %v", counts)
+	}
+}
+
+func TestMergeKeepsDistinctUnion(t *testing.T) {
+	n := NewLogNormalizer()
+	var logs []models.ParsedLog
+	for i := 0; i < 6; i++ {
+		content := "connection refused by upstream"
+		if i%2 == 1 {
+			content = "connection refused by upstream server"
+		}
+		logs = append(logs, models.ParsedLog{Content: content, Trace: fmt.Sprintf("t%d", i), ServiceName: "svc"})
+	}
+
+	groups, err := n.Normalize(logs)
+	if err != nil {
+		t.Fatalf("Normalize returned error: %v", err)
+	}
+	merged := NewGroupMerger(config.MergeConfig{}, n).Merge(groups)
+	if len(merged) != 1 {
+		t.Fatalf("expected groups to merge, got %d", len(merged))
+	}
+	if got := merged[0].DistinctCounts[EntityTrace]; got != 6 {
+		t.Errorf("expected 6 distinct traces after merge, got %d", got)
+	}
+}
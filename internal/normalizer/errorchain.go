@@ -0,0 +1,45 @@
+package normalizer
+
+import (
+	"strings"
+)
+
+// defaultChainSeparators split wrapped Go errors like "handler: spin: wallet: context deadline exceeded"
+var defaultChainSeparators = []string{": "}
+
+// SplitErrorChain decomposes a wrapped error message into its chain, outermost first.
+// It returns nil when the message is not a chain (fewer than two non-empty segments).
+func SplitErrorChain(content string, separators []string) []string {
+	if len(separators) == 0 {
+		separators = defaultChainSeparators
+	}
+
+	segments := []string{content}
+	for _, sep := range separators {
+		var split []string
+		for _, segment := range segments {
+			split = append(split, strings.Split(segment, sep)...)
+		}
+		segments = split
+	}
+
+	var chain []string
+	for _, segment := range segments {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			chain = append(chain, segment)
+		}
+	}
+	if len(chain) < 2 {
+		return nil
+	}
+
+	return chain
+}
+
+// RootCause returns the innermost cause of a message: the last chain segment, or the message itself
+func RootCause(content string, separators []string) string {
+	if chain := SplitErrorChain(content, separators); len(chain) > 0 {
+		return chain[len(chain)-1]
+	}
+	return strings.TrimSpace(content)
+}
@@ -0,0 +1,45 @@
+package normalizer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"log-analyzer/pkg/models"
+)
+
+func TestSplitErrorChain(t *testing.T) {
+	chain := SplitErrorChain("handler: spin: wallet: context deadline exceeded", nil)
+	want := []string{"handler", "spin", "wallet", "context deadline exceeded"}
+	if !reflect.DeepEqual(chain, want) {
+		t.Errorf("SplitErrorChain = %v, want %v", chain, want)
+	}
+
+	if chain := SplitErrorChain("context deadline exceeded", nil); chain != nil {
+		t.Errorf("expected no chain for a plain message, got %v", chain)
+	}
+
+	if got := RootCause("spin -> wallet -> insufficient balance", []string{" -> "}); got != "insufficient balance" {
+		t.Errorf("RootCause with custom separator = %q", got)
+	}
+}
+
+func TestNormalizeRecordsRootCause(t *testing.T) {
+	logs := []models.ParsedLog{
+		{Timestamp: time.Now(), Content: "handler: spin: wallet: context deadline exceeded", ServiceName: "pp-slot-api", Caller: "spin.go:10", Level: models.LevelError},
+		{Timestamp: time.Now(), Content: "settle: wallet: context deadline exceeded", ServiceName: "wallet-api", Caller: "settle.go:20", Level: models.LevelError},
+	}
+
+	groups, err := NewLogNormalizer().Normalize(logs)
+	if err != nil {
+		t.Fatalf("Normalize returned error: %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(groups))
+	}
+	for _, g := range groups {
+		if g.RootCause != "context deadline exceeded" {
+			t.Errorf("group %s root cause = %q", g.ServiceName, g.RootCause)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package normalizer
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"log-analyzer/internal/config"
+	"log-analyzer/pkg/models"
+)
+
+// registryStateVersion is bumped when the persisted registry format changes
+const registryStateVersion = 1
+
+// FingerprintSplit forces logs of a group whose raw content matches Pattern into their own group
+type FingerprintSplit struct {
+	Fingerprint string
+	Name        string
+	Pattern     *regexp.Regexp
+}
+
+// NewFingerprintSplits compiles configured splits; invalid patterns are skipped
+// (they are rejected by config validation)
+func NewFingerprintSplits(specs []config.FingerprintSplitConfig) []FingerprintSplit {
+	splits := make([]FingerprintSplit, 0, len(specs))
+	for _, spec := range specs {
+		compiled, err := regexp.Compile(spec.Pattern)
+		if err != nil {
+			continue
+		}
+		splits = append(splits, FingerprintSplit{Fingerprint: spec.Fingerprint, Name: spec.Name, Pattern: compiled})
+	}
+	return splits
+}
+
+// applySplits returns the split fingerprint when a split matches, or the fingerprint unchanged
+func applySplits(fingerprint, content string, splits []FingerprintSplit) string {
+	for _, split := range splits {
+		if split.Fingerprint == fingerprint && split.Pattern.MatchString(content) {
+			hash := sha256.Sum256([]byte(fingerprint + "|split|" + split.Name))
+			return fmt.Sprintf("v%d:%x", FingerprintVersion, hash)
+		}
+	}
+	return fingerprint
+}
+
+// FingerprintRegistry keeps fingerprints stable across rule changes.
+// It remembers a sample of every group seen; when the ruleset changes, the samples are
+// re-fingerprinted and new→old aliases are recorded, so the first-seen fingerprint stays
+// canonical for history, suppressions and ticket links. Manual aliases force-merge groups
+// and take precedence.
+type FingerprintRegistry struct {
+	path   string
+	manual map[string]string
+	state  registryState
+}
+
+// registryState is the persisted form of the registry
+type registryState struct {
+	Version   int                      `json:"version"`
+	RulesetID string                   `json:"ruleset_id"`
+	Groups    map[string]registryEntry `json:"groups"`  // keyed by canonical fingerprint
+	Aliases   map[string]string        `json:"aliases"` // current fingerprint -> canonical fingerprint
+}
+
+// registryEntry is a representative sample of a known group
+type registryEntry struct {
+	ServiceName string    `json:"service_name"`
+	Caller      string    `json:"caller"`
+	Content     string    `json:"content"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// NewFingerprintRegistry creates a registry persisted at path (empty keeps it in memory)
+func NewFingerprintRegistry(path string, manualAliases map[string]string) *FingerprintRegistry {
+	return &FingerprintRegistry{
+		path:   path,
+		manual: manualAliases,
+		state:  newRegistryState(),
+	}
+}
+
+// newRegistryState creates an empty registry state
+func newRegistryState() registryState {
+	return registryState{
+		Version: registryStateVersion,
+		Groups:  make(map[string]registryEntry),
+		Aliases: make(map[string]string),
+	}
+}
+
+// Load reads the registry from disk; a missing file starts an empty registry
+func (r *FingerprintRegistry) Load() error {
+	if r.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read fingerprint registry: %w", err)
+	}
+
+	state := newRegistryState()
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse fingerprint registry %s: %w", r.path, err)
+	}
+	if state.Version != registryStateVersion {
+		return fmt.Errorf("unsupported fingerprint registry version %d in %s", state.Version, r.path)
+	}
+	r.state = state
+
+	return nil
+}
+
+// Save writes the registry to disk, replacing the file atomically
+func (r *FingerprintRegistry) Save() error {
+	if r.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(r.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal fingerprint registry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create fingerprint registry directory: %w", err)
+	}
+
+	tmpPath := r.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write fingerprint registry: %w", err)
+	}
+	if err := os.Rename(tmpPath, r.path); err != nil {
+		return fmt.Errorf("failed to replace fingerprint registry: %w", err)
+	}
+
+	return nil
+}
+
+// Migrate re-fingerprints known groups when the normalizer's ruleset differs from the stored one,
+// aliasing each new fingerprint to the group's canonical one. It returns the number of aliases added.
+func (r *FingerprintRegistry) Migrate(n *LogNormalizer) int {
+	rulesetID := n.RulesetID()
+	if r.state.RulesetID == rulesetID {
+		return 0
+	}
+	if r.state.RulesetID == "" {
+		r.state.RulesetID = rulesetID
+		return 0
+	}
+
+	canonicals := make([]string, 0, len(r.state.Groups))
+	for fingerprint := range r.state.Groups {
+		canonicals = append(canonicals, fingerprint)
+	}
+	sort.Strings(canonicals)
+
+	added := 0
+	for _, canonical := range canonicals {
+		entry := r.state.Groups[canonical]
+		newFingerprint := n.Fingerprint(models.ParsedLog{
+			Content:     entry.Content,
+			ServiceName: entry.ServiceName,
+			Caller:      entry.Caller,
+		})
+		if newFingerprint == canonical || r.Resolve(newFingerprint) == canonical {
+			// Unchanged, or already aliased here (e.g. a manual force-merge target)
+			continue
+		}
+		if _, known := r.state.Groups[newFingerprint]; known {
+			// The new fingerprint is another known group's; keep both groups apart
+			continue
+		}
+		if _, aliased := r.state.Aliases[newFingerprint]; aliased {
+			// Several groups collapse into one new fingerprint; the first one keeps it
+			continue
+		}
+		r.state.Aliases[newFingerprint] = canonical
+		added++
+	}
+	r.state.RulesetID = rulesetID
+
+	return added
+}
+
+// Resolve follows manual and migration aliases to the canonical fingerprint
+func (r *FingerprintRegistry) Resolve(fingerprint string) string {
+	seen := map[string]bool{fingerprint: true}
+	for {
+		next, ok := r.manual[fingerprint]
+		if !ok {
+			next, ok = r.state.Aliases[fingerprint]
+		}
+		if !ok || seen[next] {
+			return fingerprint
+		}
+		seen[next] = true
+		fingerprint = next
+	}
+}
+
+// Apply rewrites groups to their canonical fingerprints, folding groups that resolve to the same one.
+// A merged group takes the registered fingerprint among its members (else the smallest), and
+// the other members are aliased to it so the group keeps its identity in later runs.
+func (r *FingerprintRegistry) Apply(n *LogNormalizer, groups []models.ErrorGroup) []models.ErrorGroup {
+	index := make(map[string]int, len(groups))
+	result := make([]models.ErrorGroup, 0, len(groups))
+
+	for _, group := range groups {
+		group.Fingerprint = r.canonicalMember(&group)
+		if i, exists := index[group.Fingerprint]; exists {
+			foldGroup(n, &result[i], group)
+			continue
+		}
+		index[group.Fingerprint] = len(result)
+		result = append(result, group)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].TotalCount > result[j].TotalCount
+	})
+	return result
+}
+
+// canonicalMember resolves a group's fingerprint and merged fingerprints, picks the canonical
+// one and aliases the other members to it. The merged fingerprints keep the other members.
+func (r *FingerprintRegistry) canonicalMember(group *models.ErrorGroup) string {
+	resolved := r.Resolve(group.Fingerprint)
+	if len(group.MergedFingerprints) == 0 {
+		return resolved
+	}
+
+	seen := map[string]bool{resolved: true}
+	members := []string{resolved}
+	for _, fingerprint := range group.MergedFingerprints {
+		if fingerprint = r.Resolve(fingerprint); !seen[fingerprint] {
+			seen[fingerprint] = true
+			members = append(members, fingerprint)
+		}
+	}
+	sort.Strings(members)
+
+	canonical := members[0]
+	for _, fingerprint := range members {
+		if _, known := r.state.Groups[fingerprint]; known {
+			canonical = fingerprint
+			break
+		}
+	}
+
+	others := make([]string, 0, len(members)-1)
+	for _, fingerprint := range members {
+		if fingerprint == canonical {
+			continue
+		}
+		others = append(others, fingerprint)
+		if _, manual := r.manual[fingerprint]; !manual {
+			r.state.Aliases[fingerprint] = canonical
+			delete(r.state.Groups, fingerprint)
+		}
+	}
+	group.MergedFingerprints = others
+	return canonical
+}
+
+// Register records a sample of each group so it can be re-fingerprinted after rule changes
+func (r *FingerprintRegistry) Register(groups []models.ErrorGroup) {
+	for _, group := range groups {
+		if len(group.Samples) == 0 {
+			continue
+		}
+		sample := group.Samples[0]
+		r.state.Groups[group.Fingerprint] = registryEntry{
+			ServiceName: group.ServiceName,
+			Caller:      sample.Caller,
+			Content:     sample.Content,
+			LastSeen:    group.LastSeen,
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package normalizer
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"log-analyzer/internal/config"
+	"log-analyzer/pkg/models"
+)
+
+func registryLogs(contents ...string) []models.ParsedLog {
+	logs := make([]models.ParsedLog, len(contents))
+	for i, content := range contents {
+		logs[i] = models.ParsedLog{
+			Timestamp:   time.Date(2025, 1, 1, 10, 0, i, 0, time.UTC),
+			Content:     content,
+			Level:       models.LevelError,
+			ServiceName: "pp-slot-api",
+			Caller:      "spin.go:42",
+		}
+	}
+	return logs
+}
+
+func TestFingerprintsAreVersioned(t *testing.T) {
+	fp := NewLogNormalizer().Fingerprint(registryLogs("redis timeout")[0])
+	if !strings.HasPrefix(fp, "v2:") {
+		t.Errorf("expected versioned fingerprint, got %q", fp)
+	}
+	if short := models.ShortFingerprint(fp); len(short) != 8 || strings.Contains(short, ":") {
+		t.Errorf("unexpected short fingerprint %q", short)
+	}
+}
+
+func TestFingerprintRegistryMigratesOnRuleChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fingerprints.json")
+	logs := registryLogs("timeout after 30ms")
+
+	// First run: only numbers are masked
+	oldCfg := DefaultNormalizationConfig()
+	oldCfg.Maskers = NewMaskers([]config.MaskerConfig{{Builtin: "num"}})
+	oldNormalizer := NewLogNormalizerWithConfig(oldCfg)
+	oldGroups, _ := oldNormalizer.Normalize(logs)
+
+	registry := NewFingerprintRegistry(path, nil)
+	registry.Migrate(oldNormalizer)
+	registry.Register(registry.Apply(oldNormalizer, oldGroups))
+	if err := registry.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Second run: durations are masked too, which changes the fingerprint
+	newNormalizer := NewLogNormalizer()
+	newGroups, _ := newNormalizer.Normalize(logs)
+	if newGroups[0].Fingerprint == oldGroups[0].Fingerprint {
+		t.Fatal("expected the rule change to change the fingerprint")
+	}
+
+	registry = NewFingerprintRegistry(path, nil)
+	if err := registry.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if added := registry.Migrate(newNormalizer); added != 1 {
+		t.Errorf("expected 1 alias, got %d", added)
+	}
+	if got := registry.Resolve(newGroups[0].Fingerprint); got != oldGroups[0].Fingerprint {
+		t.Errorf("new fingerprint resolves to %q, want the first-seen %q", got, oldGroups[0].Fingerprint)
+	}
+
+	// Groups keep the first-seen fingerprint so history and known-issue links still match
+	applied := registry.Apply(newNormalizer, newGroups)
+	if len(applied) != 1 || applied[0].Fingerprint != oldGroups[0].Fingerprint {
+		t.Errorf("expected the group to keep fingerprint %q, got %+v", oldGroups[0].Fingerprint, applied)
+	}
+	registry.Register(applied)
+	if _, ok := registry.state.Groups[oldGroups[0].Fingerprint]; !ok || len(registry.state.Groups) != 1 {
+		t.Errorf("expected the registry to stay keyed by the first-seen fingerprint, got %v", registry.state.Groups)
+	}
+}
+
+func TestFingerprintRegistryManualAliasesAndSplits(t *testing.T) {
+	n := NewLogNormalizer()
+	get := n.Fingerprint(registryLogs("redis get failed")[0])
+	set := n.Fingerprint(registryLogs("redis set failed")[0])
+
+	groups, _ := n.Normalize(registryLogs("redis get failed", "redis set failed", "redis set failed"))
+	merged := NewFingerprintRegistry("", map[string]string{get: set}).Apply(n, groups)
+	if len(merged) != 1 || merged[0].Fingerprint != set || merged[0].TotalCount != 3 {
+		t.Errorf("expected manual alias to force-merge into %s, got %+v", set, merged)
+	}
+
+	cfg := DefaultNormalizationConfig()
+	cfg.Splits = NewFingerprintSplits([]config.FingerprintSplitConfig{
+		{Fingerprint: n.Fingerprint(registryLogs(`timeout on "cache"`)[0]), Name: "wallet", Pattern: `"wallet"`},
+	})
+	split, _ := NewLogNormalizerWithConfig(cfg).Normalize(registryLogs(`timeout on "wallet"`, `timeout on "cache"`))
+	if len(split) != 2 {
+		t.Errorf("expected the split to produce 2 groups, got %d", len(split))
+	}
+}
+
+func TestFingerprintRegistryKeepsRegisteredMergeMember(t *testing.T) {
+	n := NewLogNormalizer()
+	registry := NewFingerprintRegistry("", nil)
+	registry.Register([]models.ErrorGroup{{Fingerprint: "v2:bbb", Samples: registryLogs("redis timeout on get")}})
+
+	// This run the other variant dominated, but the registered member stays canonical
+	merged := models.ErrorGroup{Fingerprint: "v2:aaa", MergedFingerprints: []string{"v2:bbb"}, TotalCount: 3}
+	applied := registry.Apply(n, []models.ErrorGroup{merged})
+	if applied[0].Fingerprint != "v2:bbb" || len(applied[0].MergedFingerprints) != 1 || applied[0].MergedFingerprints[0] != "v2:aaa" {
+		t.Errorf("expected the registered fingerprint to stay canonical, got %s (merged %v)", applied[0].Fingerprint, applied[0].MergedFingerprints)
+	}
+	if got := registry.Resolve("v2:aaa"); got != "v2:bbb" {
+		t.Errorf("expected the other member to alias to v2:bbb, got %s", got)
+	}
+}
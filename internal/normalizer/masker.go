@@ -0,0 +1,144 @@
+package normalizer
+
+import (
+	"regexp"
+	"strings"
+
+	"log-analyzer/internal/config"
+)
+
+// Masker replaces variable parts of a message with a typed placeholder
+type Masker struct {
+	Name        string
+	Placeholder string
+	pattern     *regexp.Regexp
+	validate    func(string) bool
+	services    map[string]bool // empty: applies to all services
+}
+
+// builtinMaskers returns the built-in maskers in their default order.
+// Patterns match lowercased content; more specific maskers run first
+// (e.g. durations and IPs before floats, floats before integers).
+func builtinMaskers() []Masker {
+	return []Masker{
+		// Redacted card and phone numbers fold into <NUM> like the numeric IDs the card
+		// detector's Luhn check cannot tell apart; first so the hex masker keeps off hashes
+		{Name: "redacted_num", Placeholder: "<NUM>",
+			pattern: regexp.MustCompile(`\[(?:card|phone)(?::[0-9a-f]+)?\]`)},
+		{Name: "uuid", Placeholder: "<UUID>",
+			pattern: regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|\b[0-9a-f]{32}\b`)},
+		{Name: "email", Placeholder: "<EMAIL>",
+			pattern: regexp.MustCompile(`[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}`)},
+		{Name: "url", Placeholder: "<URL>",
+			pattern: regexp.MustCompile(`\b[a-z][a-z0-9+.-]*://[^\s"'<>]+`)},
+		{Name: "path", Placeholder: "<PATH>",
+			pattern: regexp.MustCompile(`(?:^|[\s"'=(])((?:/[a-z0-9._~%-]+){2,}/?)`)},
+		{Name: "ip", Placeholder: "<IP>",
+			pattern: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}(?::\d{1,5})?\b|\b(?:[0-9a-f]{1,4}:){7}[0-9a-f]{1,4}\b`)},
+		{Name: "duration", Placeholder: "<DURATION>",
+			pattern: regexp.MustCompile(`\b(?:\d+(?:\.\d+)?(?:ns|us|µs|ms|s|m|h))+\b`)},
+		{Name: "hex", Placeholder: "<HEX>",
+			pattern:  regexp.MustCompile(`\b0x[0-9a-f]+\b|\b[0-9a-f]{8,}\b`),
+			validate: hasDigitAndLetter},
+		{Name: "quoted", Placeholder: "<STR>",
+			pattern: regexp.MustCompile(`"[^"]*"|'[^']*'`)},
+		{Name: "float", Placeholder: "<FLOAT>",
+			pattern: regexp.MustCompile(`\b\d+\.\d+\b`)},
+		{Name: "num", Placeholder: "<NUM>",
+			pattern: regexp.MustCompile(`\b\d+\b`)},
+	}
+}
+
+// NewMaskers builds the ordered masker list from configuration.
+// An empty configuration enables all built-in maskers in their default order.
+// Unknown built-ins and invalid patterns are skipped (they are rejected by config validation).
+func NewMaskers(specs []config.MaskerConfig) []Masker {
+	builtins := make(map[string]Masker)
+	var defaults []Masker
+	for _, m := range builtinMaskers() {
+		builtins[m.Name] = m
+		defaults = append(defaults, m)
+	}
+
+	if len(specs) == 0 {
+		return defaults
+	}
+
+	var maskers []Masker
+	for _, spec := range specs {
+		var m Masker
+		if spec.Builtin != "" {
+			builtin, ok := builtins[spec.Builtin]
+			if !ok {
+				continue
+			}
+			m = builtin
+		} else {
+			compiled, err := regexp.Compile(spec.Pattern)
+			if err != nil {
+				continue
+			}
+			m = Masker{Name: spec.Name, Placeholder: spec.Placeholder, pattern: compiled}
+			if m.Placeholder == "" {
+				m.Placeholder = "<" + strings.ToUpper(spec.Name) + ">"
+			}
+		}
+
+		if len(spec.Services) > 0 {
+			m.services = make(map[string]bool, len(spec.Services))
+			for _, svc := range spec.Services {
+				m.services[svc] = true
+			}
+		}
+		maskers = append(maskers, m)
+	}
+
+	return maskers
+}
+
+// AppliesTo reports whether the masker is enabled for a service
+func (m Masker) AppliesTo(serviceName string) bool {
+	return len(m.services) == 0 || m.services[serviceName]
+}
+
+// Mask replaces every match (or its first capture group) with the placeholder
+func (m Masker) Mask(s string) string {
+	masked, _ := m.MaskValues(s)
+	return masked
+}
+
+// MaskValues is Mask that also returns the replaced values, in order
+func (m Masker) MaskValues(s string) (string, []string) {
+	locs := m.pattern.FindAllStringSubmatchIndex(s, -1)
+	if len(locs) == 0 {
+		return s, nil
+	}
+
+	var values []string
+	var sb strings.Builder
+	last := 0
+	for _, loc := range locs {
+		start, end := loc[0], loc[1]
+		if len(loc) >= 4 && loc[2] >= 0 {
+			start, end = loc[2], loc[3]
+		}
+		if m.validate != nil && !m.validate(s[start:end]) {
+			continue
+		}
+		sb.WriteString(s[last:start])
+		sb.WriteString(m.Placeholder)
+		values = append(values, s[start:end])
+		last = end
+	}
+	sb.WriteString(s[last:])
+
+	return sb.String(), values
+}
+
+// hasDigitAndLetter filters hex candidates so plain words like "deadline" are kept
+func hasDigitAndLetter(s string) bool {
+	s = strings.TrimPrefix(s, "0x")
+	hasDigit := strings.ContainsAny(s, "0123456789")
+	hasLetter := strings.ContainsAny(s, "abcdef")
+	return hasDigit && (hasLetter || len(s) >= 16)
+}
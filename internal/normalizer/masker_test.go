@@ -0,0 +1,79 @@
+package normalizer
+
+import (
+	"testing"
+
+	"log-analyzer/internal/config"
+)
+
+func TestNormalizeContentBuiltinMaskers(t *testing.T) {
+	n := NewLogNormalizer()
+	cfg := DefaultNormalizationConfig()
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"uuid", "player 550e8400-e29b-41d4-a716-446655440000 not found", "player <UUID> not found"},
+		{"ip and port", "dial tcp 10.0.3.17:6379: connect: connection refused", "dial tcp <IP>: connect: connection refused"},
+		{"duration", "request took 1m30.5s, limit 500ms", "request took <DURATION>, limit <DURATION>"},
+		{"hex", "tx 0x1f3a failed, hash 9f86d081884c7d65", "tx <HEX> failed, hash <HEX>"},
+		{"plain words kept", "deadline exceeded", "deadline exceeded"},
+		{"email", "send to Ops@Example.com failed", "send to <EMAIL> failed"},
+		{"url", "GET https://api.example.com/v1/spin?id=3 returned 502", "get <URL> returned <NUM>"},
+		{"path", "open /data/games/42/config.json: no such file", "open <PATH>: no such file"},
+		{"quoted", `unknown field "bonusId"`, "unknown field <STR>"},
+		{"float and int", "balance 12.50 below 20", "balance <FLOAT> below <NUM>"},
+		{"redacted card like numeric id", "order [CARD] not found", "order <NUM> not found"},
+		{"hashed card", "order [CARD:9f86d081] not found", "order <NUM> not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := n.normalizeContent(tt.content, "svc", cfg); got != tt.want {
+				t.Errorf("normalizeContent(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMaskersFromConfig(t *testing.T) {
+	maskers := NewMaskers([]config.MaskerConfig{
+		{Name: "game", Pattern: `\b(vs\d+\w*)`, Services: []string{"pp-slot-api"}},
+		{Builtin: "num"},
+	})
+	if len(maskers) != 2 {
+		t.Fatalf("expected 2 maskers, got %d", len(maskers))
+	}
+
+	n := NewLogNormalizer()
+	cfg := DefaultNormalizationConfig()
+	cfg.Maskers = maskers
+	cfg.ReplaceLiterals = map[string]string{"lc-jade-prod": "<ENV>"}
+
+	got := n.normalizeContent("LC-JADE-PROD game vs20olympgate round 7", "pp-slot-api", cfg)
+	if want := "<ENV> game <GAME> round <NUM>"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Service-scoped maskers are skipped for other services
+	got = n.normalizeContent("game vs20olympgate round 7", "other", cfg)
+	if want := "game vs20olympgate round <NUM>"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReplaceLiteralsFixedOrder(t *testing.T) {
+	n := NewLogNormalizer()
+	cfg := DefaultNormalizationConfig()
+	cfg.Maskers = nil
+	cfg.ReplaceLiterals = map[string]string{"foo": "<A>", "foobar": "<B>", "bar": "<C>", "oba": "<D>"}
+
+	// Longest literal first, whatever the map iteration order
+	for i := 0; i < 50; i++ {
+		if got, want := n.normalizeContent("x foobar y", "svc", cfg), "x <B> y"; got != want {
+			t.Fatalf("run %d: got %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package normalizer
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"log-analyzer/internal/config"
+	"log-analyzer/pkg/models"
+)
+
+// Merge defaults, used for thresholds left unset in the configuration
+const (
+	defaultMergeJaccardThreshold        = 0.8
+	defaultMergeEditSimilarityThreshold = 0.9
+)
+
+// GroupMerger folds near-duplicate error groups of the same service and caller together.
+// Two groups are similar when the Jaccard index of their token sets or their
+// token-level edit similarity (1 - distance / longer length) reaches the threshold.
+type GroupMerger struct {
+	normalizer       *LogNormalizer
+	jaccardThreshold float64
+	editSimThreshold float64
+}
+
+// NewGroupMerger creates a group merger from configuration.
+// The normalizer's configuration sets the peak window recomputed for merged groups.
+func NewGroupMerger(cfg config.MergeConfig, n *LogNormalizer) *GroupMerger {
+	jaccard := cfg.JaccardThreshold
+	if jaccard <= 0 {
+		jaccard = defaultMergeJaccardThreshold
+	}
+	editSim := cfg.EditSimilarityThreshold
+	if editSim <= 0 {
+		editSim = defaultMergeEditSimilarityThreshold
+	}
+
+	return &GroupMerger{
+		normalizer:       n,
+		jaccardThreshold: jaccard,
+		editSimThreshold: editSim,
+	}
+}
+
+// Merge returns the groups with near-duplicates merged, sorted by total count descending.
+// Larger groups absorb smaller ones, so the most frequent variant's content is kept; the
+// merged group takes the smallest member fingerprint, so its identity does not depend on counts.
+// Only groups with the same service and, unless grouping by content alone, the same caller merge.
+func (m *GroupMerger) Merge(groups []models.ErrorGroup) []models.ErrorGroup {
+	sorted := make([]models.ErrorGroup, len(groups))
+	copy(sorted, groups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].TotalCount > sorted[j].TotalCount
+	})
+
+	var merged []models.ErrorGroup
+	var tokens [][]string // tokens of each merged representative
+	for _, group := range sorted {
+		groupTokens := strings.Fields(group.NormalizedContent)
+
+		target := -1
+		for i := range merged {
+			if m.sameKey(merged[i], group) && m.similar(tokens[i], groupTokens) {
+				target = i
+				break
+			}
+		}
+
+		if target < 0 {
+			merged = append(merged, group)
+			tokens = append(tokens, groupTokens)
+			continue
+		}
+		foldGroup(m.normalizer, &merged[target], group)
+	}
+
+	for i := range merged {
+		canonicalizeMembers(&merged[i])
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].TotalCount > merged[j].TotalCount
+	})
+	return merged
+}
+
+// sameKey reports whether two groups share the parts of the group key other than content:
+// the service and, unless grouping by content alone, the normalized caller
+func (m *GroupMerger) sameKey(a, b models.ErrorGroup) bool {
+	if a.ServiceName != b.ServiceName {
+		return false
+	}
+	_, callerA := groupKey("", a.CallerFile, m.normalizer.config.GroupBy)
+	_, callerB := groupKey("", b.CallerFile, m.normalizer.config.GroupBy)
+	return callerA == callerB
+}
+
+// canonicalizeMembers gives a merged group the smallest of its member fingerprints,
+// listing the others as merged fingerprints
+func canonicalizeMembers(group *models.ErrorGroup) {
+	if len(group.MergedFingerprints) == 0 {
+		return
+	}
+	members := append([]string{group.Fingerprint}, group.MergedFingerprints...)
+	sort.Strings(members)
+	group.Fingerprint = members[0]
+	group.MergedFingerprints = members[1:]
+}
+
+// similar reports whether two token sequences are near-duplicates
+func (m *GroupMerger) similar(a, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return false
+	}
+	return jaccard(a, b) >= m.jaccardThreshold || editSimilarity(a, b) >= m.editSimThreshold
+}
+
+// foldGroup merges group into target: counts, time and pod distributions, samples and member fingerprints
+func foldGroup(n *LogNormalizer, target *models.ErrorGroup, group models.ErrorGroup) {
+	target.TotalCount += group.TotalCount
+
+	if group.Level.Rank() > target.Level.Rank() {
+		target.Level = group.Level
+	}
+
+	target.TimeDistribution = addCounts(target.TimeDistribution, group.TimeDistribution)
+	target.TimeSeries = mergeSeries(target.TimeSeries, group.TimeSeries)
+	if !group.FirstSeen.IsZero() && (target.FirstSeen.IsZero() || group.FirstSeen.Before(target.FirstSeen)) {
+		target.FirstSeen = group.FirstSeen
+	}
+	if group.LastSeen.After(target.LastSeen) {
+		target.LastSeen = group.LastSeen
+	}
+	if len(group.PodCounts) > 0 {
+		target.PodCounts = addCounts(target.PodCounts, group.PodCounts)
+	}
+
+	// Keep as many samples as normalization kept, re-sampled so the combined group
+	// keeps its first-seen and last-seen logs and diverse pods and traces
+	limit := len(target.Samples)
+	if len(group.Samples) > limit {
+		limit = len(group.Samples)
+	}
+	sampler := newGroupSampler(limit, rand.New(rand.NewSource(samplingSeed)))
+	for _, sample := range target.Samples {
+		sampler.add(sample)
+	}
+	for _, sample := range group.Samples {
+		sampler.add(sample)
+	}
+	target.Samples = sampler.samples()
+
+	// Merged groups keep the same per-group trace bound as normalization
+	for trace, first := range group.TraceFirstSeen {
+		if target.TraceFirstSeen == nil {
+			target.TraceFirstSeen = make(map[string]time.Time)
+		}
+		existing, ok := target.TraceFirstSeen[trace]
+		if ok && first.Before(existing) {
+			target.TraceFirstSeen[trace] = first
+		} else if !ok && len(target.TraceFirstSeen) < n.config.MaxTraces {
+			target.TraceFirstSeen[trace] = first
+		}
+	}
+
+	target.EntitySketches = mergeEntitySketches(target.EntitySketches, group.EntitySketches)
+	target.DistinctCounts = DistinctCounts(target.EntitySketches)
+
+	target.MaskedValues = mergeMaskedValueStats(target.MaskedValues, group.MaskedValues, n.topValues())
+
+	if group.Fingerprint != target.Fingerprint {
+		target.MergedFingerprints = append(target.MergedFingerprints, group.Fingerprint)
+	}
+	target.MergedFingerprints = append(target.MergedFingerprints, group.MergedFingerprints...)
+
+	target.PeakWindow = CalculatePeakWindow(target.TimeSeries, peakWindowSize(n.config))
+}
+
+// addCounts adds src counts into dst, allocating dst if needed
+func addCounts(dst, src map[string]int) map[string]int {
+	if dst == nil {
+		dst = make(map[string]int, len(src))
+	}
+	for key, count := range src {
+		dst[key] += count
+	}
+	return dst
+}
+
+// jaccard returns the Jaccard index of the token sets of a and b
+func jaccard(a, b []string) float64 {
+	setA := make(map[string]bool, len(a))
+	for _, token := range a {
+		setA[token] = true
+	}
+	setB := make(map[string]bool, len(b))
+	for _, token := range b {
+		setB[token] = true
+	}
+
+	intersection := 0
+	for token := range setA {
+		if setB[token] {
+			intersection++
+		}
+	}
+	union := len(setA) + len(setB) - intersection
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
+
+// editSimilarity returns 1 - (token edit distance / length of the longer sequence)
+func editSimilarity(a, b []string) float64 {
+	longer := len(a)
+	if len(b) > longer {
+		longer = len(b)
+	}
+	if longer == 0 {
+		return 0
+	}
+	return 1 - float64(tokenEditDistance(a, b))/float64(longer)
+}
+
+// tokenEditDistance computes the Levenshtein distance between token sequences
+func tokenEditDistance(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// minInt returns the smallest of the given integers
+func minInt(values ...int) int {
+	result := values[0]
+	for _, v := range values[1:] {
+		if v < result {
+			result = v
+		}
+	}
+	return result
+}
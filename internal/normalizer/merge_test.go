@@ -0,0 +1,126 @@
+package normalizer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"log-analyzer/internal/config"
+	"log-analyzer/pkg/models"
+)
+
+func mergeGroup(fingerprint, service, content string, count int, hour string) models.ErrorGroup {
+	return models.ErrorGroup{
+		Fingerprint:       fingerprint,
+		NormalizedContent: content,
+		ServiceName:       service,
+		Level:             models.LevelError,
+		TotalCount:        count,
+		Samples:           []models.ParsedLog{{Timestamp: time.Date(2025, 1, 1, 10, 0, count, 0, time.UTC), Content: content}},
+		TimeDistribution:  map[string]int{hour: count},
+	}
+}
+
+func TestGroupMergerMergesNearDuplicates(t *testing.T) {
+	groups := []models.ErrorGroup{
+		mergeGroup("aaa", "redis-proxy", "redis timeout after <DURATION> on mget from pool main in cluster east", 4, "11:00"),
+		mergeGroup("bbb", "redis-proxy", "redis timeout after <DURATION> on get from pool main in cluster east", 10, "10:00"),
+		mergeGroup("ccc", "redis-proxy", "connection refused", 3, "10:00"),
+		mergeGroup("ddd", "redis-proxy", "connection reset", 2, "10:00"),
+		mergeGroup("eee", "other-api", "redis timeout after <DURATION> on get from pool main in cluster east", 5, "10:00"),
+	}
+
+	merged := NewGroupMerger(config.MergeConfig{}, NewLogNormalizer()).Merge(groups)
+
+	if len(merged) != 4 {
+		t.Fatalf("expected 4 groups after merging, got %d", len(merged))
+	}
+
+	// The larger group's content is kept, the smallest member fingerprint identifies the group
+	top := merged[0]
+	if top.Fingerprint != "aaa" || top.TotalCount != 14 || !strings.Contains(top.NormalizedContent, " get ") {
+		t.Errorf("expected bbb and aaa to merge as aaa with 14 logs, got %s with %d", top.Fingerprint, top.TotalCount)
+	}
+	if len(top.MergedFingerprints) != 1 || top.MergedFingerprints[0] != "bbb" {
+		t.Errorf("unexpected merged fingerprints: %v", top.MergedFingerprints)
+	}
+	if top.TimeDistribution["10:00"] != 10 || top.TimeDistribution["11:00"] != 4 {
+		t.Errorf("time distributions not merged: %v", top.TimeDistribution)
+	}
+	if len(top.Samples) != 1 {
+		t.Errorf("expected sample count to stay at the normalization limit, got %d", len(top.Samples))
+	}
+
+	// Short messages differing in one token and groups of other services stay separate
+	for _, g := range merged {
+		if g.Fingerprint == "ccc" && g.TotalCount != 3 {
+			t.Errorf("connection refused should not absorb connection reset")
+		}
+	}
+}
+
+func TestGroupMergerKeepsFirstAndLastSamples(t *testing.T) {
+	at := func(minute int, pod string) models.ParsedLog {
+		return models.ParsedLog{Timestamp: time.Date(2025, 1, 1, 10, minute, 0, 0, time.UTC), Pod: pod, Content: "redis timeout"}
+	}
+	larger := mergeGroup("aaa", "redis-proxy", "redis timeout after <DURATION> on get from pool main in cluster east", 10, "10:00")
+	larger.Samples = []models.ParsedLog{at(0, "redis-1"), at(10, "redis-1"), at(20, "redis-1")}
+	smaller := mergeGroup("bbb", "redis-proxy", "redis timeout after <DURATION> on mget from pool main in cluster east", 4, "10:00")
+	smaller.Samples = []models.ParsedLog{at(30, "redis-2"), at(50, "redis-3")}
+
+	merged := NewGroupMerger(config.MergeConfig{}, NewLogNormalizer()).Merge([]models.ErrorGroup{larger, smaller})
+	if len(merged) != 1 {
+		t.Fatalf("expected 1 group after merging, got %d", len(merged))
+	}
+
+	samples := merged[0].Samples
+	if len(samples) != 3 {
+		t.Fatalf("expected 3 samples, got %d", len(samples))
+	}
+	if !samples[0].Timestamp.Equal(at(0, "").Timestamp) || !samples[2].Timestamp.Equal(at(50, "").Timestamp) {
+		t.Errorf("expected the first-seen and last-seen samples to be kept, got %v .. %v", samples[0].Timestamp, samples[2].Timestamp)
+	}
+	if samples[1].Pod != "redis-2" {
+		t.Errorf("expected the middle sample to come from the unseen pod, got %s", samples[1].Pod)
+	}
+}
+
+func TestGroupMergerKeepsDistinctGroupsApart(t *testing.T) {
+	mysql := mergeGroup("aaa", "wallet-api", "failed to connect to mysql", 5, "10:00")
+	redis := mergeGroup("bbb", "wallet-api", "failed to connect to redis", 3, "10:00")
+	if merged := NewGroupMerger(config.MergeConfig{}, NewLogNormalizer()).Merge([]models.ErrorGroup{mysql, redis}); len(merged) != 2 {
+		t.Errorf("short messages differing in one token should not merge, got %d groups", len(merged))
+	}
+
+	content := "redis timeout after <DURATION> on get from pool main in cluster east"
+	handler := mergeGroup("ccc", "wallet-api", content, 5, "10:00")
+	handler.CallerFile = "handler/spin.go"
+	worker := mergeGroup("ddd", "wallet-api", strings.Replace(content, " get ", " mget ", 1), 3, "10:00")
+	worker.CallerFile = "worker/settle.go"
+	if merged := NewGroupMerger(config.MergeConfig{}, NewLogNormalizer()).Merge([]models.ErrorGroup{handler, worker}); len(merged) != 2 {
+		t.Errorf("groups of different callers should not merge, got %d groups", len(merged))
+	}
+
+	byContent := DefaultNormalizationConfig()
+	byContent.GroupBy = GroupByContent
+	if merged := NewGroupMerger(config.MergeConfig{}, NewLogNormalizerWithConfig(byContent)).Merge([]models.ErrorGroup{handler, worker}); len(merged) != 1 {
+		t.Errorf("callers are not part of the key when grouping by content, got %d groups", len(merged))
+	}
+}
+
+func TestGroupMergerFingerprintIndependentOfCounts(t *testing.T) {
+	content := "redis timeout after <DURATION> on get from pool main in cluster east"
+	merger := NewGroupMerger(config.MergeConfig{}, NewLogNormalizer())
+
+	getWins := merger.Merge([]models.ErrorGroup{
+		mergeGroup("bbb", "redis-proxy", content, 10, "10:00"),
+		mergeGroup("aaa", "redis-proxy", strings.Replace(content, " get ", " mget ", 1), 4, "10:00"),
+	})
+	mgetWins := merger.Merge([]models.ErrorGroup{
+		mergeGroup("bbb", "redis-proxy", content, 4, "10:00"),
+		mergeGroup("aaa", "redis-proxy", strings.Replace(content, " get ", " mget ", 1), 10, "10:00"),
+	})
+	if getWins[0].Fingerprint != "aaa" || mgetWins[0].Fingerprint != "aaa" {
+		t.Errorf("expected the merged group to keep aaa regardless of counts, got %s and %s", getWins[0].Fingerprint, mgetWins[0].Fingerprint)
+	}
+}
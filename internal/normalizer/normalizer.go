@@ -3,6 +3,7 @@ package normalizer
 import (
 	"crypto/sha256"
 	"fmt"
+	"math/rand"
 	"regexp"
 	"sort"
 	"strings"
@@ -11,102 +12,228 @@ import (
 	"log-analyzer/pkg/models"
 )
 
+// FingerprintVersion identifies the fingerprint algorithm and built-in masking rules.
+// Bump it whenever a change alters fingerprints of unchanged messages; fingerprints
+// are prefixed with it ("v2:<sha256>"). Version 1 fingerprints were unprefixed.
+const FingerprintVersion = 2
+
+// defaultMaxTraces bounds the traces remembered per group unless configured
+const defaultMaxTraces = 10000
+
 // LogNormalizer implements the Normalizer interface
 type LogNormalizer struct {
-	uuidRegex *regexp.Regexp
+	spaceRegex *regexp.Regexp
+	config     NormalizationConfig
 }
 
 // NewLogNormalizer creates a new log normalizer
 func NewLogNormalizer() *LogNormalizer {
-	// Regex to match common UUID patterns
-	uuidRegex := regexp.MustCompile(`(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|\b[0-9a-f]{32}\b`)
+	return NewLogNormalizerWithConfig(DefaultNormalizationConfig())
+}
 
+// NewLogNormalizerWithConfig creates a log normalizer whose Normalize uses the given configuration
+func NewLogNormalizerWithConfig(config NormalizationConfig) *LogNormalizer {
 	return &LogNormalizer{
-		uuidRegex: uuidRegex,
+		spaceRegex: regexp.MustCompile(`\s+`),
+		config:     config,
 	}
 }
 
 // NormalizationConfig contains configuration for normalization
 type NormalizationConfig struct {
-	// ReplaceLiterals replaces specific literals with placeholders
+	// ReplaceLiterals replaces specific literals with placeholders (applied before maskers)
 	ReplaceLiterals map[string]string
-	// MinSamplesPerGroup minimum samples to keep per error group
-	MinSamplesPerGroup int
-	// MaxSamplesPerGroup maximum samples to keep per error group
-	MaxSamplesPerGroup int
+	// Maskers replace variable parts of messages with typed placeholders, in order
+	Maskers []Masker
+	// ChainSeparators split wrapped errors into their chain (default ": ")
+	ChainSeparators []string
+	// Splits force messages matching a pattern out of a group into their own group
+	Splits []FingerprintSplit
+	// Caller normalizes callers before grouping (nil keeps them unchanged)
+	Caller *CallerNormalizer
+	// GroupBy selects what identifies a group within a service (default content and caller)
+	GroupBy string
+	// Entities are extra fields (e.g. player ID) whose distinct values are counted per group
+	Entities []EntityField
+	// TopValues is the number of most common masked values kept per placeholder position (0 disables)
+	TopValues int
+	// SampleSize is the number of representative samples kept per error group:
+	// first-seen, last-seen and a reservoir sample across pods and traces
+	SampleSize int
+	// Location is the timezone used for hour-of-day buckets (timestamps are UTC internally)
+	Location *time.Location
+	// BucketSize is the granularity of each group's time series
+	BucketSize time.Duration
+	// PeakWindow is the sliding window used to find each group's peak density
+	PeakWindow time.Duration
+	// MaxTraces bounds the traces remembered per group for correlation (0 disables)
+	MaxTraces int
 }
 
 // DefaultNormalizationConfig returns default configuration
 func DefaultNormalizationConfig() NormalizationConfig {
 	return NormalizationConfig{
-		MinSamplesPerGroup: 3,
-		MaxSamplesPerGroup: 5,
-		ReplaceLiterals:    make(map[string]string),
+		SampleSize:      5,
+		TopValues:       defaultTopValues,
+		ReplaceLiterals: make(map[string]string),
+		Maskers:         NewMaskers(nil),
+		Location:        time.Local,
+		BucketSize:      time.Minute,
+		PeakWindow:      5 * time.Minute,
+		MaxTraces:       defaultMaxTraces,
 	}
 }
 
 // Normalize processes logs and groups them by fingerprint
 func (n *LogNormalizer) Normalize(logs []models.ParsedLog) ([]models.ErrorGroup, error) {
-	config := DefaultNormalizationConfig()
-	return n.NormalizeWithConfig(logs, config)
+	return n.NormalizeWithConfig(logs, n.config)
 }
 
 // NormalizeWithConfig processes logs with custom configuration
 func (n *LogNormalizer) NormalizeWithConfig(logs []models.ParsedLog, config NormalizationConfig) ([]models.ErrorGroup, error) {
 	// Group logs by fingerprint
-	groupMap := make(map[string]*models.ErrorGroup)
-	timeDistribution := make(map[string]map[string]int) // fingerprint -> hour -> count
+	groups := newGroupBuilder(config)
 
 	for _, log := range logs {
-		// Normalize content
-		normalizedContent := n.normalizeContent(log.Content, config)
-
-		// Calculate fingerprint
-		fingerprint := n.calculateFingerprint(normalizedContent, log.ServiceName, log.Caller)
-
-		// Initialize group if not exists
-		if _, exists := groupMap[fingerprint]; !exists {
-			groupMap[fingerprint] = &models.ErrorGroup{
-				Fingerprint:       fingerprint,
-				NormalizedContent: normalizedContent,
-				ServiceName:       log.ServiceName,
-				CallerFile:        log.Caller,
-				TotalCount:        0,
-				Samples:           []models.ParsedLog{},
-				TimeDistribution:  make(map[string]int),
-			}
-			timeDistribution[fingerprint] = make(map[string]int)
+		n.addLog(groups, log, config)
+	}
+
+	return groups.build(), nil
+}
+
+// addLog normalizes a log and adds it to its group
+func (n *LogNormalizer) addLog(groups *groupBuilder, log models.ParsedLog, config NormalizationConfig) {
+	// Normalize content, keeping the masked values
+	normalizedContent, values := n.normalizeContentWithValues(log.Content, log.ServiceName, config)
+
+	// Calculate fingerprint
+	fingerprint := n.fingerprintFor(normalizedContent, log, config)
+
+	groups.add(fingerprint, normalizedContent, values, log)
+}
+
+// groupBuilder accumulates logs into error groups keyed by fingerprint
+type groupBuilder struct {
+	config   NormalizationConfig
+	location *time.Location
+	groupMap map[string]*models.ErrorGroup
+	samplers map[string]*groupSampler
+	buckets  map[string]map[time.Time]int       // fingerprint -> bucket start -> count
+	values   map[string]map[string]*spaceSaving // fingerprint -> position -> sketch
+	rng      *rand.Rand
+}
+
+// newGroupBuilder creates a group builder for the given configuration
+func newGroupBuilder(config NormalizationConfig) *groupBuilder {
+	location := config.Location
+	if location == nil {
+		location = time.Local
+	}
+
+	return &groupBuilder{
+		config:   config,
+		location: location,
+		groupMap: make(map[string]*models.ErrorGroup),
+		samplers: make(map[string]*groupSampler),
+		buckets:  make(map[string]map[time.Time]int),
+		values:   make(map[string]map[string]*spaceSaving),
+		rng:      rand.New(rand.NewSource(samplingSeed)),
+	}
+}
+
+// add adds a log to the group identified by fingerprint, creating it if needed
+func (b *groupBuilder) add(fingerprint, normalizedContent string, values []maskedValue, log models.ParsedLog) {
+	// Initialize group if not exists
+	if _, exists := b.groupMap[fingerprint]; !exists {
+		b.groupMap[fingerprint] = &models.ErrorGroup{
+			Fingerprint:       fingerprint,
+			NormalizedContent: normalizedContent,
+			ServiceName:       log.ServiceName,
+			CallerFile:        b.config.Caller.Normalize(log.Caller),
+			TotalCount:        0,
+			Samples:           []models.ParsedLog{},
+			TimeDistribution:  make(map[string]int),
+			PodCounts:         make(map[string]int),
+			ErrorChain:        SplitErrorChain(normalizedContent, b.config.ChainSeparators),
+			RootCause:         RootCause(normalizedContent, b.config.ChainSeparators),
 		}
+		b.samplers[fingerprint] = newGroupSampler(b.config.SampleSize, b.rng)
+		b.buckets[fingerprint] = make(map[time.Time]int)
+		b.values[fingerprint] = make(map[string]*spaceSaving)
+	}
 
-		group := groupMap[fingerprint]
-		group.TotalCount++
+	group := b.groupMap[fingerprint]
+	group.TotalCount++
+
+	// Track the most severe level seen in the group
+	if log.Level.Rank() > group.Level.Rank() {
+		group.Level = log.Level
+	}
 
-		// Add sample if under max limit
-		if len(group.Samples) < config.MaxSamplesPerGroup {
-			group.Samples = append(group.Samples, log)
+	// Remember the first occurrence of each trace for cross-service correlation
+	if log.Trace != "" && b.config.MaxTraces > 0 {
+		if group.TraceFirstSeen == nil {
+			group.TraceFirstSeen = make(map[string]time.Time)
 		}
+		first, ok := group.TraceFirstSeen[log.Trace]
+		if ok && log.Timestamp.Before(first) {
+			group.TraceFirstSeen[log.Trace] = log.Timestamp
+		} else if !ok && len(group.TraceFirstSeen) < b.config.MaxTraces {
+			group.TraceFirstSeen[log.Trace] = log.Timestamp
+		}
+	}
+
+	// Break down by pod when Kubernetes metadata is available
+	if log.Pod != "" {
+		group.PodCounts[log.Pod]++
+	}
 
-		// Update time distribution (by hour)
-		hour := log.Timestamp.Hour()
-		hourKey := fmt.Sprintf("%02d:00", hour)
-		group.TimeDistribution[hourKey]++
+	// Count masked values per placeholder position
+	if b.config.TopValues > 0 {
+		for _, v := range values {
+			counter, ok := b.values[fingerprint][v.position]
+			if !ok {
+				counter = newSpaceSaving(defaultSketchCapacity)
+				b.values[fingerprint][v.position] = counter
+			}
+			counter.add(v.value)
+		}
+	}
+
+	// Count distinct affected entities
+	countEntities(group, log, b.config.Entities)
+
+	// Offer the log to the group's sampler
+	b.samplers[fingerprint].add(log)
+
+	// Track exact first/last occurrence and the absolute time series
+	if group.FirstSeen.IsZero() || log.Timestamp.Before(group.FirstSeen) {
+		group.FirstSeen = log.Timestamp
+	}
+	if log.Timestamp.After(group.LastSeen) {
+		group.LastSeen = log.Timestamp
 	}
+	b.buckets[fingerprint][log.Timestamp.Truncate(bucketSize(b.config))]++
 
+	// Update time distribution (by hour of the display timezone)
+	hour := log.Timestamp.In(b.location).Hour()
+	hourKey := fmt.Sprintf("%02d:00", hour)
+	group.TimeDistribution[hourKey]++
+}
+
+// build returns the accumulated groups sorted by total count descending
+func (b *groupBuilder) build() []models.ErrorGroup {
 	// Convert map to slice
 	var errorGroups []models.ErrorGroup
-	for _, group := range groupMap {
-		// Sort samples by timestamp for consistency
-		sort.Slice(group.Samples, func(i, j int) bool {
-			return group.Samples[i].Timestamp.Before(group.Samples[j].Timestamp)
-		})
-
-		// Ensure minimum samples
-		if len(group.Samples) > config.MinSamplesPerGroup {
-			group.Samples = group.Samples[:config.MinSamplesPerGroup]
-		}
+	for fingerprint, group := range b.groupMap {
+		// Select representative samples, in timestamp order
+		group.Samples = b.samplers[fingerprint].samples()
 
-		// Calculate peak window
-		group.PeakWindow = n.calculatePeakWindow(group.TimeDistribution)
+		// Build the time series and find the peak window
+		group.TimeSeries = seriesFromBuckets(b.buckets[fingerprint])
+		group.MaskedValues = maskedValueStats(b.values[fingerprint], b.config.TopValues)
+		group.DistinctCounts = DistinctCounts(group.EntitySketches)
+		group.PeakWindow = CalculatePeakWindow(group.TimeSeries, peakWindowSize(b.config))
 
 		errorGroups = append(errorGroups, *group)
 	}
@@ -116,34 +243,66 @@ func (n *LogNormalizer) NormalizeWithConfig(logs []models.ParsedLog, config Norm
 		return errorGroups[i].TotalCount > errorGroups[j].TotalCount
 	})
 
-	return errorGroups, nil
+	return errorGroups
 }
 
 // normalizeContent normalizes log content for fingerprinting
-func (n *LogNormalizer) normalizeContent(content string, config NormalizationConfig) string {
+func (n *LogNormalizer) normalizeContent(content, serviceName string, config NormalizationConfig) string {
+	normalized, _ := n.normalizeContentWithValues(content, serviceName, config)
+	return normalized
+}
+
+// normalizeContentWithValues normalizes log content and returns the masked values by position.
+// A placeholder's values are attributed to positions only when every value is still visible
+// in the result (a later masker, e.g. quoted strings, may swallow earlier placeholders).
+func (n *LogNormalizer) normalizeContentWithValues(content, serviceName string, config NormalizationConfig) (string, []maskedValue) {
 	// Convert to lowercase
 	normalized := strings.ToLower(content)
 
-	// Replace UUIDs with placeholder
-	normalized = n.uuidRegex.ReplaceAllString(normalized, "[UUID]")
-
-	// Replace numbers with placeholder (to group similar errors with different IDs)
-	numRegex := regexp.MustCompile(`\b\d+\b`)
-	normalized = numRegex.ReplaceAllString(normalized, "[NUM]")
+	// Replace custom literals first so they are not broken up by maskers
+	for _, literal := range orderedLiterals(config.ReplaceLiterals) {
+		normalized = strings.ReplaceAll(normalized, strings.ToLower(literal), config.ReplaceLiterals[literal])
+	}
 
-	// Replace custom literals
-	for literal, placeholder := range config.ReplaceLiterals {
-		normalized = strings.ReplaceAll(normalized, strings.ToLower(literal), placeholder)
+	// Replace variable parts (IDs, numbers, durations...) with typed placeholders
+	captured := make(map[string][]string)
+	var placeholders []string
+	for _, masker := range config.Maskers {
+		if masker.AppliesTo(serviceName) {
+			var values []string
+			normalized, values = masker.MaskValues(normalized)
+			if len(values) > 0 {
+				if _, seen := captured[masker.Placeholder]; !seen {
+					placeholders = append(placeholders, masker.Placeholder)
+				}
+				captured[masker.Placeholder] = append(captured[masker.Placeholder], values...)
+			}
+		}
 	}
 
 	// Collapse multiple spaces
-	spaceRegex := regexp.MustCompile(`\s+`)
-	normalized = spaceRegex.ReplaceAllString(normalized, " ")
+	normalized = n.spaceRegex.ReplaceAllString(normalized, " ")
 
 	// Trim spaces
 	normalized = strings.TrimSpace(normalized)
 
-	return normalized
+	var masked []maskedValue
+	if config.TopValues > 0 {
+		for _, placeholder := range placeholders {
+			values := captured[placeholder]
+			if strings.Count(normalized, placeholder) != len(values) {
+				continue
+			}
+			for i, value := range values {
+				if len(value) > maxMaskedValueLength {
+					value = value[:maxMaskedValueLength] + maskedValueTruncateMark
+				}
+				masked = append(masked, maskedValue{position: fmt.Sprintf("%s#%d", placeholder, i+1), value: value})
+			}
+		}
+	}
+
+	return normalized, masked
 }
 
 // calculateFingerprint calculates a SHA256 fingerprint for error grouping
@@ -153,45 +312,154 @@ func (n *LogNormalizer) calculateFingerprint(normalizedContent, serviceName, cal
 
 	// Calculate SHA256 hash
 	hash := sha256.Sum256([]byte(combined))
-	return fmt.Sprintf("%x", hash)
+	return fmt.Sprintf("v%d:%x", FingerprintVersion, hash)
 }
 
-// calculatePeakWindow finds the time window with highest error density
-func (n *LogNormalizer) calculatePeakWindow(distribution map[string]int) *models.PeakWindow {
-	if len(distribution) == 0 {
-		return nil
+// Fingerprint returns the fingerprint a log gets under the current rules
+func (n *LogNormalizer) Fingerprint(log models.ParsedLog) string {
+	normalizedContent := n.normalizeContent(log.Content, log.ServiceName, n.config)
+	return n.fingerprintFor(normalizedContent, log, n.config)
+}
+
+// fingerprintFor fingerprints normalized content of a log under the caller
+// normalization, grouping mode and splits of config
+func (n *LogNormalizer) fingerprintFor(normalizedContent string, log models.ParsedLog, config NormalizationConfig) string {
+	content, caller := groupKey(normalizedContent, config.Caller.Normalize(log.Caller), config.GroupBy)
+	fingerprint := n.calculateFingerprint(content, log.ServiceName, caller)
+	return applySplits(fingerprint, log.Content, config.Splits)
+}
+
+// orderedLiterals returns the literals in application order: longest first, then lexicographic,
+// so overlapping literals (e.g. "foo" and "foobar") always replace the same way
+func orderedLiterals(literals map[string]string) []string {
+	ordered := make([]string, 0, len(literals))
+	for literal := range literals {
+		ordered = append(ordered, literal)
 	}
+	sort.Slice(ordered, func(i, j int) bool {
+		a, b := strings.ToLower(ordered[i]), strings.ToLower(ordered[j])
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		if a != b {
+			return a < b
+		}
+		return ordered[i] < ordered[j]
+	})
+	return ordered
+}
 
-	// Find the hour with maximum count
-	var peakHour string
-	maxCount := 0
+// RulesetID returns a digest of the fingerprint version and the rules that affect fingerprints.
+// A different ruleset means stored fingerprints must be migrated.
+func (n *LogNormalizer) RulesetID() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "v%d\n", FingerprintVersion)
 
-	for hour, count := range distribution {
-		if count > maxCount {
-			maxCount = count
-			peakHour = hour
+	for _, m := range n.config.Maskers {
+		services := make([]string, 0, len(m.services))
+		for svc := range m.services {
+			services = append(services, svc)
 		}
+		sort.Strings(services)
+		fmt.Fprintf(&sb, "masker %s %s %s %s\n", m.Name, m.Placeholder, m.pattern, strings.Join(services, ","))
 	}
 
-	if peakHour == "" {
-		return nil
+	literals := make([]string, 0, len(n.config.ReplaceLiterals))
+	for literal, placeholder := range n.config.ReplaceLiterals {
+		literals = append(literals, strings.ToLower(literal)+"="+placeholder)
 	}
+	sort.Strings(literals)
+	fmt.Fprintf(&sb, "literals %s\n", strings.Join(literals, ","))
+
+	fmt.Fprintf(&sb, "caller %s\ngroup-by %s\n", n.config.Caller.describe(), n.config.GroupBy)
 
-	// Parse the peak hour
-	peakTime, err := time.Parse("2006-01-02T15:00:00Z", peakHour)
-	if err != nil {
+	for _, split := range n.config.Splits {
+		fmt.Fprintf(&sb, "split %s %s %s\n", split.Fingerprint, split.Name, split.Pattern)
+	}
+
+	hash := sha256.Sum256([]byte(sb.String()))
+	return fmt.Sprintf("%x", hash[:8])
+}
+
+// bucketSize returns the configured time series granularity (default one minute)
+func bucketSize(config NormalizationConfig) time.Duration {
+	if config.BucketSize <= 0 {
+		return time.Minute
+	}
+	return config.BucketSize
+}
+
+// peakWindowSize returns the configured peak window, at least one bucket long
+func peakWindowSize(config NormalizationConfig) time.Duration {
+	if config.PeakWindow < bucketSize(config) {
+		return bucketSize(config)
+	}
+	return config.PeakWindow
+}
+
+// BucketSizeFor derives the time series granularity from the peak window:
+// five buckets per window, at least one minute, so the window slides in fine steps
+func BucketSizeFor(window time.Duration) time.Duration {
+	bucket := (window / 5).Truncate(time.Minute)
+	if bucket < time.Minute {
+		return time.Minute
+	}
+	return bucket
+}
+
+// seriesFromBuckets converts bucket counts to a time series sorted by bucket start
+func seriesFromBuckets(buckets map[time.Time]int) []models.TimeBucket {
+	series := make([]models.TimeBucket, 0, len(buckets))
+	for start, count := range buckets {
+		series = append(series, models.TimeBucket{Start: start, Count: count})
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].Start.Before(series[j].Start)
+	})
+	return series
+}
+
+// mergeSeries adds two time series bucket by bucket
+func mergeSeries(a, b []models.TimeBucket) []models.TimeBucket {
+	buckets := make(map[time.Time]int, len(a)+len(b))
+	for _, bucket := range a {
+		buckets[bucket.Start] += bucket.Count
+	}
+	for _, bucket := range b {
+		buckets[bucket.Start] += bucket.Count
+	}
+	return seriesFromBuckets(buckets)
+}
+
+// CalculatePeakWindow slides a window over the time series and returns the one with
+// the most errors; density is errors per minute within the window
+func CalculatePeakWindow(series []models.TimeBucket, window time.Duration) *models.PeakWindow {
+	if len(series) == 0 {
 		return nil
 	}
 
-	// Calculate density (errors per minute in that hour)
-	density := float64(maxCount) / 60.0
+	var peak *models.PeakWindow
+	sum, end := 0, 0
+	for i, start := range series {
+		// Extend the window to cover buckets starting before start+window
+		for end < len(series) && series[end].Start.Before(start.Start.Add(window)) {
+			sum += series[end].Count
+			end++
+		}
 
-	return &models.PeakWindow{
-		Start:   peakTime,
-		End:     peakTime.Add(1 * time.Hour),
-		Count:   maxCount,
-		Density: density,
+		if peak == nil || sum > peak.Count {
+			peak = &models.PeakWindow{
+				Start: start.Start,
+				End:   start.Start.Add(window),
+				Count: sum,
+			}
+		}
+
+		sum -= series[i].Count
 	}
+
+	peak.Density = float64(peak.Count) / window.Minutes()
+	return peak
 }
 
 // NormalizationStats contains statistics about normalization
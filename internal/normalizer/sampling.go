@@ -0,0 +1,142 @@
+package normalizer
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+
+	"log-analyzer/pkg/models"
+)
+
+const (
+	// reservoirFactor oversizes the reservoir relative to the sample size,
+	// leaving room to pick samples from diverse pods and traces
+	reservoirFactor = 4
+	// samplingSeed makes sampling reproducible for the same input
+	samplingSeed = 1
+)
+
+// sampledLog is a log with its arrival position in the group
+type sampledLog struct {
+	seq int
+	log models.ParsedLog
+}
+
+// groupSampler keeps representative samples of a group spanning the whole window:
+// the first-seen and last-seen logs plus a uniform reservoir of the rest.
+type groupSampler struct {
+	size      int
+	rng       *rand.Rand
+	seen      int
+	first     *sampledLog
+	last      *sampledLog
+	reservoir []sampledLog
+}
+
+// newGroupSampler creates a sampler that selects up to size samples
+func newGroupSampler(size int, rng *rand.Rand) *groupSampler {
+	return &groupSampler{size: size, rng: rng}
+}
+
+// add offers a log to the sampler (reservoir sampling, algorithm R)
+func (s *groupSampler) add(log models.ParsedLog) {
+	entry := sampledLog{seq: s.seen, log: log}
+	s.seen++
+
+	if s.first == nil || log.Timestamp.Before(s.first.log.Timestamp) {
+		first := entry
+		s.first = &first
+	}
+	if s.last == nil || !log.Timestamp.Before(s.last.log.Timestamp) {
+		last := entry
+		s.last = &last
+	}
+
+	capacity := s.size * reservoirFactor
+	if len(s.reservoir) < capacity {
+		s.reservoir = append(s.reservoir, entry)
+		return
+	}
+	if j := s.rng.Intn(s.seen); j < capacity {
+		s.reservoir[j] = entry
+	}
+}
+
+// samples selects the final samples in timestamp order: first-seen, last-seen, then reservoir
+// logs favouring unseen pods and traces, ties broken by distance in time from chosen samples
+func (s *groupSampler) samples() []models.ParsedLog {
+	if s.first == nil || s.size <= 0 {
+		return []models.ParsedLog{}
+	}
+
+	chosen := []sampledLog{*s.first}
+	if s.size > 1 && s.last.seq != s.first.seq {
+		chosen = append(chosen, *s.last)
+	}
+
+	picked := make(map[int]bool)
+	pods := make(map[string]bool)
+	traces := make(map[string]bool)
+	for _, c := range chosen {
+		picked[c.seq] = true
+		pods[c.log.Pod] = true
+		traces[c.log.Trace] = true
+	}
+
+	for len(chosen) < s.size {
+		best := -1
+		bestScore, bestDistance := -1, time.Duration(-1)
+		for i, candidate := range s.reservoir {
+			if picked[candidate.seq] {
+				continue
+			}
+
+			score := 0
+			if !pods[candidate.log.Pod] {
+				score += 2
+			}
+			if candidate.log.Trace != "" && !traces[candidate.log.Trace] {
+				score++
+			}
+			distance := minDistance(candidate.log.Timestamp, chosen)
+
+			if score > bestScore || (score == bestScore && distance > bestDistance) {
+				best, bestScore, bestDistance = i, score, distance
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		candidate := s.reservoir[best]
+		chosen = append(chosen, candidate)
+		picked[candidate.seq] = true
+		pods[candidate.log.Pod] = true
+		traces[candidate.log.Trace] = true
+	}
+
+	sort.SliceStable(chosen, func(i, j int) bool {
+		return chosen[i].log.Timestamp.Before(chosen[j].log.Timestamp)
+	})
+
+	result := make([]models.ParsedLog, len(chosen))
+	for i, c := range chosen {
+		result[i] = c.log
+	}
+	return result
+}
+
+// minDistance returns the smallest absolute time distance from t to the chosen samples
+func minDistance(t time.Time, chosen []sampledLog) time.Duration {
+	min := time.Duration(-1)
+	for _, c := range chosen {
+		d := t.Sub(c.log.Timestamp)
+		if d < 0 {
+			d = -d
+		}
+		if min < 0 || d < min {
+			min = d
+		}
+	}
+	return min
+}
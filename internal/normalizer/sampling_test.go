@@ -0,0 +1,50 @@
+package normalizer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"log-analyzer/pkg/models"
+)
+
+func TestSamplesSpanWindowAndPods(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var logs []models.ParsedLog
+	for i := 0; i < 200; i++ {
+		pod := "pod-a"
+		if i%50 == 25 {
+			pod = fmt.Sprintf("pod-%d", i)
+		}
+		logs = append(logs, models.ParsedLog{
+			Timestamp:   start.Add(time.Duration(i) * time.Minute),
+			Content:     "redis timeout",
+			Level:       models.LevelError,
+			ServiceName: "pp-slot-api",
+			Pod:         pod,
+		})
+	}
+	// Input order differs from timestamp order
+	logs[0], logs[199] = logs[199], logs[0]
+
+	cfg := DefaultNormalizationConfig()
+	cfg.SampleSize = 3
+	groups, err := NewLogNormalizerWithConfig(cfg).Normalize(logs)
+	if err != nil {
+		t.Fatalf("Normalize returned error: %v", err)
+	}
+
+	samples := groups[0].Samples
+	if len(samples) != 3 {
+		t.Fatalf("expected 3 samples, got %d", len(samples))
+	}
+	if !samples[0].Timestamp.Equal(start) {
+		t.Errorf("first sample should be the first-seen log, got %s", samples[0].Timestamp)
+	}
+	if !samples[2].Timestamp.Equal(start.Add(199 * time.Minute)) {
+		t.Errorf("last sample should be the last-seen log, got %s", samples[2].Timestamp)
+	}
+	if samples[1].Pod == "pod-a" {
+		t.Errorf("expected the middle sample to come from another pod, got %s", samples[1].Pod)
+	}
+}
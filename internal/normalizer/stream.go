@@ -0,0 +1,48 @@
+package normalizer
+
+import (
+	"log-analyzer/pkg/models"
+)
+
+// StreamingNormalizer groups logs one at a time, keeping only per-group state: bounded samples,
+// masked-value and distinct-count sketches, time buckets and (optionally capped) traces.
+// Logs are never collected, so memory grows with the number of groups, not with the input.
+// It uses the exact-match fingerprint strategy; Drain needs a learning pass over the whole input.
+type StreamingNormalizer struct {
+	base   *LogNormalizer
+	config NormalizationConfig
+	groups *groupBuilder
+	count  int
+}
+
+// NewStreamingNormalizer creates a streaming normalizer with the given configuration
+func NewStreamingNormalizer(config NormalizationConfig) *StreamingNormalizer {
+	return &StreamingNormalizer{
+		base:   NewLogNormalizerWithConfig(config),
+		config: config,
+		groups: newGroupBuilder(config),
+	}
+}
+
+// Add normalizes a log and adds it to its group
+func (s *StreamingNormalizer) Add(log models.ParsedLog) {
+	s.base.addLog(s.groups, log, s.config)
+	s.count++
+}
+
+// Consume adds every log received from in until it is closed
+func (s *StreamingNormalizer) Consume(in <-chan models.ParsedLog) {
+	for log := range in {
+		s.Add(log)
+	}
+}
+
+// Count returns the number of logs added so far
+func (s *StreamingNormalizer) Count() int {
+	return s.count
+}
+
+// Groups returns the error groups built so far, sorted by count
+func (s *StreamingNormalizer) Groups() []models.ErrorGroup {
+	return s.groups.build()
+}
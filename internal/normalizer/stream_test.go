@@ -0,0 +1,109 @@
+package normalizer
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"log-analyzer/pkg/models"
+)
+
+func TestStreamingMatchesBatch(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var logs []models.ParsedLog
+	for i := 0; i < 300; i++ {
+		logs = append(logs, models.ParsedLog{
+			Timestamp:   start.Add(time.Duration(i) * 17 * time.Second),
+			Content:     fmt.Sprintf("order %d failed: timeout after %dms", i, i%7*100),
+			Caller:      fmt.Sprintf("logic/order.go:%d", 10+i%2),
+			Level:       models.LevelError,
+			ServiceName: "pp-slot-api",
+			Pod:         fmt.Sprintf("pod-%d", i%3),
+			Trace:       fmt.Sprintf("trace-%d", i),
+		})
+	}
+
+	cfg := DefaultNormalizationConfig()
+	cfg.Location = time.UTC
+	batch, err := NewLogNormalizerWithConfig(cfg).Normalize(logs)
+	if err != nil {
+		t.Fatalf("Normalize returned error: %v", err)
+	}
+
+	stream := NewStreamingNormalizer(cfg)
+	in := make(chan models.ParsedLog, 10)
+	go func() {
+		for _, log := range logs {
+			in <- log
+		}
+		close(in)
+	}()
+	stream.Consume(in)
+	streamed := stream.Groups()
+
+	if stream.Count() != len(logs) {
+		t.Errorf("expected %d logs counted, got %d", len(logs), stream.Count())
+	}
+	if len(streamed) != len(batch) {
+		t.Fatalf("expected %d groups, got %d", len(batch), len(streamed))
+	}
+	byFingerprint := make(map[string]models.ErrorGroup)
+	for _, g := range batch {
+		byFingerprint[g.Fingerprint] = g
+	}
+	for _, g := range streamed {
+		want, ok := byFingerprint[g.Fingerprint]
+		if !ok {
+			t.Fatalf("unexpected streamed group %s", g.Fingerprint)
+		}
+		if g.TotalCount != want.TotalCount || !reflect.DeepEqual(g.TimeSeries, want.TimeSeries) ||
+			!reflect.DeepEqual(g.DistinctCounts, want.DistinctCounts) || !reflect.DeepEqual(g.Samples, want.Samples) {
+			t.Errorf("group %s differs between streaming and batch", models.ShortFingerprint(g.Fingerprint))
+		}
+	}
+}
+
+func TestStreamingCapsTraces(t *testing.T) {
+	cfg := DefaultNormalizationConfig()
+	cfg.MaxTraces = 10
+	stream := NewStreamingNormalizer(cfg)
+	for i := 0; i < 100; i++ {
+		stream.Add(models.ParsedLog{Content: "redis timeout", ServiceName: "svc", Trace: fmt.Sprintf("t%d", i)})
+	}
+
+	groups := stream.Groups()
+	if got := len(groups[0].TraceFirstSeen); got != 10 {
+		t.Errorf("expected traces capped at 10, got %d", got)
+	}
+	if got := groups[0].DistinctCounts[EntityTrace]; got < 95 || got > 105 {
+		t.Errorf("expected the trace sketch to still count about 100 traces, got %d", got)
+	}
+}
+
+func TestBatchCapsTraces(t *testing.T) {
+	var logs []models.ParsedLog
+	for i := 0; i < 100; i++ {
+		logs = append(logs, models.ParsedLog{Content: "redis timeout", ServiceName: "svc", Trace: fmt.Sprintf("t%d", i)})
+	}
+
+	cfg := DefaultNormalizationConfig()
+	cfg.MaxTraces = 10
+	groups, err := NewLogNormalizerWithConfig(cfg).Normalize(logs)
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if got := len(groups[0].TraceFirstSeen); got != 10 {
+		t.Errorf("expected traces capped at 10, got %d", got)
+	}
+
+	// Without correlation no trace occurrences are kept
+	cfg.MaxTraces = 0
+	groups, err = NewLogNormalizerWithConfig(cfg).Normalize(logs)
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if groups[0].TraceFirstSeen != nil {
+		t.Errorf("expected no traces when tracking is disabled, got %d", len(groups[0].TraceFirstSeen))
+	}
+}
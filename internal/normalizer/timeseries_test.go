@@ -0,0 +1,51 @@
+package normalizer
+
+import (
+	"testing"
+	"time"
+
+	"log-analyzer/pkg/models"
+)
+
+func TestTimeSeriesAndPeakWindow(t *testing.T) {
+	day1 := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+
+	var logs []models.ParsedLog
+	add := func(ts time.Time) {
+		logs = append(logs, models.ParsedLog{Timestamp: ts, Content: "redis timeout", Level: models.LevelError, ServiceName: "svc"})
+	}
+	add(day1)
+	add(day1.Add(30 * time.Second))
+	// Burst on day 2 at the same hour of day
+	for i := 0; i < 6; i++ {
+		add(day2.Add(time.Duration(i) * time.Minute))
+	}
+
+	cfg := DefaultNormalizationConfig()
+	cfg.PeakWindow = 5 * time.Minute
+	cfg.BucketSize = BucketSizeFor(cfg.PeakWindow)
+
+	groups, err := NewLogNormalizerWithConfig(cfg).Normalize(logs)
+	if err != nil {
+		t.Fatalf("Normalize returned error: %v", err)
+	}
+	g := groups[0]
+
+	if !g.FirstSeen.Equal(day1) || !g.LastSeen.Equal(day2.Add(5*time.Minute)) {
+		t.Errorf("first/last seen = %s / %s", g.FirstSeen, g.LastSeen)
+	}
+	if len(g.TimeSeries) != 7 || g.TimeSeries[0].Count != 2 {
+		t.Errorf("expected 7 one-minute buckets starting with 2 logs, got %+v", g.TimeSeries)
+	}
+
+	if g.PeakWindow == nil {
+		t.Fatal("expected a peak window")
+	}
+	if !g.PeakWindow.Start.Equal(day2) || g.PeakWindow.Count != 5 {
+		t.Errorf("peak window = %+v, want 5 errors starting %s", g.PeakWindow, day2)
+	}
+	if g.PeakWindow.Density != 1.0 {
+		t.Errorf("density = %.2f, want 1.00", g.PeakWindow.Density)
+	}
+}
@@ -0,0 +1,126 @@
+package normalizer
+
+import (
+	"sort"
+
+	"log-analyzer/pkg/models"
+)
+
+// Masked-value statistics defaults
+const (
+	defaultTopValues        = 5
+	defaultSketchCapacity   = 64
+	maxMaskedValueLength    = 200
+	maskedValueTruncateMark = "…"
+)
+
+// topValues returns the configured number of values kept per position (default when unset)
+func (n *LogNormalizer) topValues() int {
+	if n.config.TopValues <= 0 {
+		return defaultTopValues
+	}
+	return n.config.TopValues
+}
+
+// maskedValue is an original value replaced by a placeholder, at its position in the message
+type maskedValue struct {
+	position string // e.g. "<NUM>#2": the second <NUM> in the normalized message
+	value    string
+}
+
+// spaceSaving is a Space-Saving heavy-hitters sketch: it tracks at most capacity values,
+// and counts are exact for values never evicted (overestimated by at most the evicted minimum).
+type spaceSaving struct {
+	capacity int
+	counts   map[string]int
+}
+
+// newSpaceSaving creates a sketch tracking up to capacity values
+func newSpaceSaving(capacity int) *spaceSaving {
+	return &spaceSaving{capacity: capacity, counts: make(map[string]int)}
+}
+
+// add counts one occurrence of value
+func (s *spaceSaving) add(value string) {
+	if _, ok := s.counts[value]; ok || len(s.counts) < s.capacity {
+		s.counts[value]++
+		return
+	}
+
+	// Replace the least frequent value, inheriting its count
+	minValue, minCount := "", -1
+	for v, c := range s.counts {
+		if minCount < 0 || c < minCount || (c == minCount && v < minValue) {
+			minValue, minCount = v, c
+		}
+	}
+	delete(s.counts, minValue)
+	s.counts[value] = minCount + 1
+}
+
+// top returns the k most frequent values, ties broken by value
+func (s *spaceSaving) top(k int) []models.ValueCount {
+	return topValueCounts(s.counts, k)
+}
+
+// topValueCounts returns the k highest counts, ties broken by value
+func topValueCounts(counts map[string]int, k int) []models.ValueCount {
+	values := make([]models.ValueCount, 0, len(counts))
+	for v, c := range counts {
+		values = append(values, models.ValueCount{Value: v, Count: c})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+	if len(values) > k {
+		values = values[:k]
+	}
+	return values
+}
+
+// maskedValueStats converts per-position sketches into sorted statistics
+func maskedValueStats(sketches map[string]*spaceSaving, k int) []models.MaskedValueStats {
+	if len(sketches) == 0 {
+		return nil
+	}
+
+	stats := make([]models.MaskedValueStats, 0, len(sketches))
+	for position, sketch := range sketches {
+		stats = append(stats, models.MaskedValueStats{Position: position, TopValues: sketch.top(k)})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Position < stats[j].Position
+	})
+	return stats
+}
+
+// mergeMaskedValueStats combines the statistics of two groups, keeping the top k per position
+func mergeMaskedValueStats(a, b []models.MaskedValueStats, k int) []models.MaskedValueStats {
+	if len(b) == 0 {
+		return a
+	}
+
+	counts := make(map[string]map[string]int)
+	for _, stats := range [][]models.MaskedValueStats{a, b} {
+		for _, s := range stats {
+			if counts[s.Position] == nil {
+				counts[s.Position] = make(map[string]int)
+			}
+			for _, v := range s.TopValues {
+				counts[s.Position][v.Value] += v.Count
+			}
+		}
+	}
+
+	merged := make([]models.MaskedValueStats, 0, len(counts))
+	for position, values := range counts {
+		merged = append(merged, models.MaskedValueStats{Position: position, TopValues: topValueCounts(values, k)})
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Position < merged[j].Position
+	})
+	return merged
+}
@@ -0,0 +1,95 @@
+package normalizer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"log-analyzer/pkg/models"
+)
+
+func TestMaskedValuesPerPosition(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var logs []models.ParsedLog
+	for i := 0; i < 30; i++ {
+		table := 7
+		if i%3 == 0 {
+			table = 42
+		}
+		logs = append(logs, models.ParsedLog{
+			Timestamp:   start.Add(time.Duration(i) * time.Second),
+			Content:     fmt.Sprintf("player %d bet failed on table %d", 1000+i, table),
+			Level:       models.LevelError,
+			ServiceName: "pp-slot-api",
+		})
+	}
+
+	groups, err := NewLogNormalizer().Normalize(logs)
+	if err != nil {
+		t.Fatalf("Normalize returned error: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("expected 1 group, got %d", len(groups))
+	}
+
+	stats := groups[0].MaskedValues
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 positions, got %+v", stats)
+	}
+	if stats[0].Position != "<NUM>#1" || len(stats[0].TopValues) != defaultTopValues {
+		t.Errorf("unexpected first position: %+v", stats[0])
+	}
+	table := stats[1]
+	if table.Position != "<NUM>#2" || len(table.TopValues) != 2 {
+		t.Fatalf("unexpected second position: %+v", table)
+	}
+	if table.TopValues[0] != (models.ValueCount{Value: "7", Count: 20}) || table.TopValues[1] != (models.ValueCount{Value: "42", Count: 10}) {
+		t.Errorf("unexpected table values: %+v", table.TopValues)
+	}
+}
+
+func TestMaskedValuesDisabled(t *testing.T) {
+	cfg := DefaultNormalizationConfig()
+	cfg.TopValues = 0
+	groups, err := NewLogNormalizerWithConfig(cfg).Normalize([]models.ParsedLog{
+		{Content: "timeout after 30 retries", ServiceName: "svc"},
+	})
+	if err != nil {
+		t.Fatalf("Normalize returned error: %v", err)
+	}
+	if groups[0].MaskedValues != nil {
+		t.Errorf("expected no masked values, got %+v", groups[0].MaskedValues)
+	}
+}
+
+func TestSpaceSavingKeepsHeavyHitters(t *testing.T) {
+	sketch := newSpaceSaving(4)
+	for i := 0; i < 100; i++ {
+		sketch.add("hot")
+		sketch.add(fmt.Sprintf("cold-%d", i))
+	}
+
+	if len(sketch.counts) > 4 {
+		t.Fatalf("sketch grew beyond its capacity: %d", len(sketch.counts))
+	}
+	top := sketch.top(1)
+	if top[0].Value != "hot" || top[0].Count != 100 {
+		t.Errorf("expected hot (100), got %+v", top)
+	}
+}
+
+func TestMergeMaskedValueStats(t *testing.T) {
+	a := []models.MaskedValueStats{{Position: "<NUM>#1", TopValues: []models.ValueCount{{Value: "1", Count: 3}}}}
+	b := []models.MaskedValueStats{
+		{Position: "<NUM>#1", TopValues: []models.ValueCount{{Value: "1", Count: 2}, {Value: "2", Count: 4}}},
+		{Position: "<IP>#1", TopValues: []models.ValueCount{{Value: "10.0.0.1", Count: 1}}},
+	}
+
+	merged := mergeMaskedValueStats(a, b, 1)
+	if len(merged) != 2 || merged[0].Position != "<IP>#1" {
+		t.Fatalf("unexpected merged positions: %+v", merged)
+	}
+	if merged[1].TopValues[0] != (models.ValueCount{Value: "1", Count: 5}) || len(merged[1].TopValues) != 1 {
+		t.Errorf("unexpected merged values: %+v", merged[1].TopValues)
+	}
+}
@@ -2,14 +2,21 @@ package pipeline
 
 import (
 	"fmt"
+	"sort"
+	"time"
 
 	"log-analyzer/internal/aggregator"
 	"log-analyzer/internal/config"
+	"log-analyzer/internal/correlator"
+	"log-analyzer/internal/deploy"
 	"log-analyzer/internal/fetcher"
 	"log-analyzer/internal/interfaces"
 	"log-analyzer/internal/normalizer"
 	"log-analyzer/internal/preprocessor"
+	"log-analyzer/internal/redactor"
 	"log-analyzer/internal/reporter"
+	"log-analyzer/internal/slo"
+	"log-analyzer/internal/trend"
 	"log-analyzer/pkg/models"
 )
 
@@ -17,8 +24,12 @@ import (
 type Pipeline struct {
 	fetcher      *fetcher.Fetcher
 	preprocessor *preprocessor.LogPreprocessor
-	normalizer   *normalizer.LogNormalizer
+	redactor     *redactor.Redactor
+	normalizer   interfaces.Normalizer
+	fingerprints *normalizer.FingerprintRegistry
 	aggregator   *aggregator.LogAggregator
+	correlator   *correlator.Correlator
+	trends       *trend.History
 	reporter     *reporter.MarkdownReporter
 	config       *config.Config
 }
@@ -27,28 +38,130 @@ type Pipeline struct {
 func NewPipeline(cfg *config.Config) *Pipeline {
 	return &Pipeline{
 		fetcher:      fetcher.NewFetcher(cfg),
-		preprocessor: preprocessor.NewLogPreprocessor(),
-		normalizer:   normalizer.NewLogNormalizer(),
-		aggregator:   aggregator.NewLogAggregator(),
-		reporter:     reporter.NewMarkdownReporter(cfg.Output.ReportDir),
+		preprocessor: preprocessor.NewLogPreprocessorWithConfig(cfg.Preprocessing),
+		redactor:     redactor.NewRedactor(cfg.Redaction),
+		normalizer:   newNormalizer(cfg),
+		fingerprints: normalizer.NewFingerprintRegistry(cfg.Normalization.Fingerprints.RegistryPath, cfg.Normalization.Fingerprints.Aliases),
+		aggregator:   newAggregator(cfg),
+		correlator:   correlator.NewCorrelator(cfg.Correlation),
+		trends:       newTrendHistory(cfg),
+		reporter:     newReporter(cfg),
 		config:       cfg,
 	}
 }
 
+// newNormalizer creates the normalizer for the configured grouping strategy
+func newNormalizer(cfg *config.Config) interfaces.Normalizer {
+	if cfg.Normalization.Strategy == "drain" {
+		return normalizer.NewDrainNormalizer(normalizationConfig(cfg), cfg.Normalization.Drain)
+	}
+	return normalizer.NewLogNormalizerWithConfig(normalizationConfig(cfg))
+}
+
+// normalizationConfig builds the normalizer configuration from the analysis settings
+func normalizationConfig(cfg *config.Config) normalizer.NormalizationConfig {
+	normConfig := normalizer.DefaultNormalizationConfig()
+	normConfig.Location = cfg.Output.Location()
+	normConfig.SampleSize = cfg.Analysis.SampleSize
+	normConfig.PeakWindow = time.Duration(cfg.Analysis.Density.PeakWindowMinutes) * time.Minute
+	normConfig.BucketSize = normalizer.BucketSizeFor(normConfig.PeakWindow)
+	normConfig.Maskers = normalizer.NewMaskers(cfg.Normalization.Maskers)
+	normConfig.ChainSeparators = cfg.Normalization.ErrorChain.Separators
+	normConfig.Splits = normalizer.NewFingerprintSplits(cfg.Normalization.Fingerprints.Splits)
+	normConfig.Caller = normalizer.NewCallerNormalizer(cfg.Normalization.Caller)
+	normConfig.GroupBy = cfg.Normalization.GroupBy
+	normConfig.Entities = normalizer.NewEntityFields(cfg.Analysis.Entities)
+	// Trace first occurrences only feed correlation, so they are not kept when it is off
+	if cfg.Correlation.Disabled {
+		normConfig.MaxTraces = 0
+	} else {
+		normConfig.MaxTraces = cfg.Streaming.MaxTracesPerGroup
+	}
+	if cfg.Normalization.MaskedValues.Disabled {
+		normConfig.TopValues = 0
+	} else if cfg.Normalization.MaskedValues.TopK > 0 {
+		normConfig.TopValues = cfg.Normalization.MaskedValues.TopK
+	}
+	if len(cfg.Normalization.ReplaceLiterals) > 0 {
+		normConfig.ReplaceLiterals = cfg.Normalization.ReplaceLiterals
+	}
+	return normConfig
+}
+
+// stabilizeFingerprints migrates stored fingerprints after rule changes and applies aliases,
+// so groups keep the fingerprints used by history, suppressions and ticket links
+func (p *Pipeline) stabilizeFingerprints(groups []models.ErrorGroup) ([]models.ErrorGroup, error) {
+	if err := p.fingerprints.Load(); err != nil {
+		return nil, err
+	}
+
+	// Migration re-fingerprints stored samples, which only the exact-match strategy can reproduce
+	exact := normalizer.NewLogNormalizerWithConfig(normalizationConfig(p.config))
+	if p.config.Normalization.Strategy != "drain" {
+		if added := p.fingerprints.Migrate(exact); added > 0 {
+			fmt.Printf("🔁 正規化規則已變更：為 %d 個既有錯誤模式建立指紋別名\n", added)
+		}
+	}
+
+	groups = p.fingerprints.Apply(exact, groups)
+	p.fingerprints.Register(groups)
+	if err := p.fingerprints.Save(); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// newAggregator creates the aggregator with the configured display timezone
+func newAggregator(cfg *config.Config) *aggregator.LogAggregator {
+	a := aggregator.NewLogAggregator()
+	a.SetPeakWindow(time.Duration(cfg.Analysis.Density.PeakWindowMinutes) * time.Minute)
+	a.SetDisplayLocation(cfg.Output.Location())
+	return a
+}
+
+// newTrendHistory creates the history of hourly counts used for baselines
+func newTrendHistory(cfg *config.Config) *trend.History {
+	trendCfg := cfg.Analysis.Severity.Trend
+	return trend.NewHistory(trendCfg.HistoryPath, time.Duration(trendCfg.RetentionDays)*24*time.Hour)
+}
+
+// newReporter creates the markdown reporter with the configured display timezone
+func newReporter(cfg *config.Config) *reporter.MarkdownReporter {
+	r := reporter.NewMarkdownReporter(cfg.Output.ReportDir)
+	r.SetDisplayLocation(cfg.Output.Location())
+	return r
+}
+
 // PipelineResult represents the result of running the pipeline
 type PipelineResult struct {
-	RawLogs           []models.RawLog
-	ParsedLogs        []models.ParsedLog
+	RawLogs           []models.RawLog    // nil in streaming mode
+	ParsedLogs        []models.ParsedLog // nil in streaming mode
+	TotalRawLogs      int
+	TotalParsedLogs   int
 	ErrorGroups       []models.ErrorGroup
 	Analyses          []models.Analysis
 	AggregationResult *interfaces.AggregationResult
-	Reports           map[string]*models.Report
+	// ServiceResults is the aggregation of each service on its own, used by its report
+	ServiceResults map[string]*interfaces.AggregationResult
+	Reports        map[string]*models.Report
+	// TimeRange is the analyzed window, used for baselines and the volume query
+	TimeRange models.TimeRange
 }
 
-// Run executes the entire pipeline
+// Run executes the entire pipeline (streaming when streaming.enabled is set)
 func (p *Pipeline) Run(timeRangeStr string) (*PipelineResult, error) {
+	if p.config.Streaming.Enabled {
+		return p.RunStreaming(timeRangeStr)
+	}
+
+	timeRange, err := analysisWindow(timeRangeStr)
+	if err != nil {
+		return nil, err
+	}
 	result := &PipelineResult{
-		Reports: make(map[string]*models.Report),
+		Reports:   make(map[string]*models.Report),
+		TimeRange: timeRange,
 	}
 
 	// Step 0: Fetch from OpenSearch
@@ -66,18 +179,48 @@ func (p *Pipeline) Run(timeRangeStr string) (*PipelineResult, error) {
 
 	fmt.Printf("✅ 成功獲取 %d 條原始日誌\n", len(rawLogs))
 	result.RawLogs = rawLogs
+	result.TotalRawLogs = len(rawLogs)
 
 	// Show service distribution
 	p.printServiceDistribution(rawLogs)
 
 	// Step 1: Preprocess
 	fmt.Println("🔄 第 1 步：預處理日誌...")
-	parsedLogs, err := p.preprocessor.Process(rawLogs)
-	if err != nil {
-		return nil, fmt.Errorf("preprocessing failed: %w", err)
+	// A failure to parse any log (e.g. a log format change) still quarantines the rejects and
+	// continues with no groups, so the dead-letter file and the parse-failure report are written
+	parsedLogs, procErr := p.preprocessor.Process(rawLogs)
+	procStats := p.preprocessor.GetProcessingStats(rawLogs, parsedLogs)
+	if procErr != nil {
+		fmt.Printf("❌ %v\n", procErr)
+	} else {
+		fmt.Printf("✅ 成功解析 %d 條日誌（%d 個 worker，%.0f 條/秒）\n",
+			len(parsedLogs), procStats.Workers, procStats.LogsPerSecond)
 	}
-	fmt.Printf("✅ 成功解析 %d 條日誌\n\n", len(parsedLogs))
 	result.ParsedLogs = parsedLogs
+	result.TotalParsedLogs = len(parsedLogs)
+
+	parseFailures, err := p.quarantineRejectedLogs(procStats)
+	if err != nil {
+		return nil, fmt.Errorf("dead-letter write failed: %w", err)
+	}
+	fmt.Println()
+
+	// Detect pods with skewed clocks
+	clockSkew := preprocessor.DetectClockSkew(parsedLogs, p.config.Preprocessing.Timestamps.SkewThreshold)
+	for _, skew := range clockSkew {
+		if skew.Flagged {
+			fmt.Printf("⚠️  Pod %s（節點 %s）時鐘偏移 %s\n", skew.Pod, skew.Node, skew.MedianSkew.Round(time.Second))
+		}
+	}
+
+	// Step 1.5: Redact PII and secrets before anything reaches samples, reports or JSON
+	fmt.Println("🛡️  第 1.5 步：遮蔽敏感資訊...")
+	redactStats := p.redactor.Redact(parsedLogs)
+	fmt.Printf("✅ %d 條日誌含敏感資訊已遮蔽\n", redactStats.LogsRedacted)
+	for name, count := range redactStats.Matches {
+		fmt.Printf("   - %s: %d 處\n", name, count)
+	}
+	fmt.Println()
 
 	// Step 2: Normalize
 	fmt.Println("🔐 第 2 步：正規化和分組錯誤...")
@@ -85,7 +228,119 @@ func (p *Pipeline) Run(timeRangeStr string) (*PipelineResult, error) {
 	if err != nil {
 		return nil, fmt.Errorf("normalization failed: %w", err)
 	}
-	normStats := normalizer.GetNormalizationStats(len(parsedLogs), errorGroups)
+
+	return p.analyzeGroups(errorGroups, len(parsedLogs), parseFailures, clockSkew, result)
+}
+
+// analysisWindow returns the window ending now covered by a run over timeRangeStr
+func analysisWindow(timeRangeStr string) (models.TimeRange, error) {
+	duration, err := time.ParseDuration(timeRangeStr)
+	if err != nil {
+		return models.TimeRange{}, fmt.Errorf("invalid time range %q: %w", timeRangeStr, err)
+	}
+	end := time.Now()
+	return models.TimeRange{Start: end.Add(-duration), End: end}, nil
+}
+
+// RunStreaming executes the pipeline without materializing raw or parsed logs: fetched logs flow
+// through channels to the preprocessor workers, then are checked for clock skew, redacted and
+// grouped one at a time, so memory is bounded by the number of error groups
+func (p *Pipeline) RunStreaming(timeRangeStr string) (*PipelineResult, error) {
+	timeRange, err := analysisWindow(timeRangeStr)
+	if err != nil {
+		return nil, err
+	}
+	result := &PipelineResult{
+		Reports:   make(map[string]*models.Report),
+		TimeRange: timeRange,
+	}
+
+	rawLogs := make(chan models.RawLog, p.config.Streaming.BufferSize)
+	parsedLogs := make(chan models.ParsedLog, p.config.Streaming.BufferSize)
+
+	// Steps 0–2 run concurrently: fetch → preprocess → skew, redact and group
+	fmt.Printf("📡 第 0-2 步：串流獲取、預處理並分組日誌（過去 %s）...\n", timeRangeStr)
+	fetchErr := make(chan error, 1)
+	go func() {
+		fetchErr <- p.fetcher.StreamWithTimeWindows(timeRangeStr, rawLogs)
+	}()
+
+	// Rejects go straight to the dead-letter file instead of being collected
+	deadLetter := preprocessor.NewDeadLetterWriter(p.config.Output.DeadLetterDir)
+	var deadLetterErr error
+	onReject := func(rejected preprocessor.RejectedLog) {
+		if deadLetterErr == nil {
+			deadLetterErr = p.writeRejected(deadLetter, rejected)
+		}
+	}
+
+	var procStats preprocessor.ProcessingStats
+	procDone := make(chan struct{})
+	go func() {
+		procStats = p.preprocessor.ProcessStream(rawLogs, parsedLogs, onReject)
+		close(procDone)
+	}()
+
+	stream := normalizer.NewStreamingNormalizer(normalizationConfig(p.config))
+	skew := preprocessor.NewClockSkewTracker()
+	redactStats := redactor.Stats{Matches: make(map[string]int)}
+	for log := range parsedLogs {
+		skew.Add(log)
+		p.redactor.RedactLog(&log, &redactStats)
+		stream.Add(log)
+	}
+	<-procDone
+	deadLetterPath, closeErr := deadLetter.Close()
+	if err := <-fetchErr; err != nil {
+		return nil, fmt.Errorf("fetching failed: %w", err)
+	}
+	if deadLetterErr != nil {
+		return nil, fmt.Errorf("dead-letter write failed: %w", deadLetterErr)
+	}
+	if closeErr != nil {
+		return nil, fmt.Errorf("dead-letter write failed: %w", closeErr)
+	}
+
+	if procStats.TotalRawLogs == 0 {
+		fmt.Println("⚠️  指定時間範圍內找不到日誌。")
+		fmt.Println("   提示：嘗試更長的時間範圍（例如：-time 48h）")
+		return result, nil
+	}
+	result.TotalRawLogs = procStats.TotalRawLogs
+	result.TotalParsedLogs = procStats.SuccessfullyParsed
+	fmt.Printf("✅ 串流處理 %d 條原始日誌，成功解析 %d 條（%d 個 worker，%.0f 條/秒）\n",
+		procStats.TotalRawLogs, procStats.SuccessfullyParsed, procStats.Workers, procStats.LogsPerSecond)
+
+	parseFailures := summarizeParseFailures(procStats, deadLetterPath)
+
+	clockSkew := skew.Result(p.config.Preprocessing.Timestamps.SkewThreshold)
+	for _, s := range clockSkew {
+		if s.Flagged {
+			fmt.Printf("⚠️  Pod %s（節點 %s）時鐘偏移 %s\n", s.Pod, s.Node, s.MedianSkew.Round(time.Second))
+		}
+	}
+	fmt.Printf("✅ %d 條日誌含敏感資訊已遮蔽\n\n", redactStats.LogsRedacted)
+
+	return p.analyzeGroups(stream.Groups(), procStats.SuccessfullyParsed, parseFailures, clockSkew, result)
+}
+
+// analyzeGroups runs the steps after grouping: fingerprint stabilization and merging,
+// aggregation, correlation, analysis, reports and the analysis JSON
+func (p *Pipeline) analyzeGroups(errorGroups []models.ErrorGroup, parsedCount int, parseFailures *interfaces.ParseFailureStats,
+	clockSkew []interfaces.PodClockSkew, result *PipelineResult) (*PipelineResult, error) {
+	// Merge near-duplicates first so the registry records the merged groups
+	if !p.config.Normalization.Merge.Disabled {
+		before := len(errorGroups)
+		errorGroups = normalizer.NewGroupMerger(p.config.Normalization.Merge, normalizer.NewLogNormalizerWithConfig(normalizationConfig(p.config))).Merge(errorGroups)
+		if merged := before - len(errorGroups); merged > 0 {
+			fmt.Printf("🔗 合併了 %d 個相似的錯誤模式\n", merged)
+		}
+	}
+	errorGroups, err := p.stabilizeFingerprints(errorGroups)
+	if err != nil {
+		return nil, fmt.Errorf("fingerprint migration failed: %w", err)
+	}
+	normStats := normalizer.GetNormalizationStats(parsedCount, errorGroups)
 	fmt.Printf("✅ 分組為 %d 個唯一錯誤模式（%.1f%% 重複率）\n\n",
 		len(errorGroups), normStats.DuplicationRate*100)
 	result.ErrorGroups = errorGroups
@@ -101,25 +356,75 @@ func (p *Pipeline) Run(timeRangeStr string) (*PipelineResult, error) {
 	fmt.Printf("   - 總錯誤數：%d\n", aggStats.TotalLogs)
 	fmt.Printf("   - 服務總數：%d\n", aggStats.TotalServices)
 	fmt.Printf("   - 峰值時段：%02d:00（%d 個錯誤）\n", aggStats.PeakHour, aggStats.PeakCount)
+	if !aggResult.TimeStats.PeakWindowStart.IsZero() {
+		fmt.Printf("   - 峰值窗口：%s（%d 個錯誤）\n",
+			aggResult.TimeStats.PeakWindowStart.In(p.config.Output.Location()).Format("2006-01-02 15:04"), aggResult.TimeStats.PeakWindowCount)
+	}
 	fmt.Printf("   - 平均密度：%.2f 錯誤/分鐘\n\n", aggStats.AverageDensity)
+	aggResult.ParseFailures = parseFailures
+	aggResult.ClockSkew = clockSkew
+	if p.config.Normalization.ErrorChain.GroupByRootCause {
+		aggResult.RootCauses = p.aggregator.GroupByRootCause(errorGroups)
+	}
+	if !p.config.Correlation.Disabled {
+		aggResult.Correlations, aggResult.Cascades = p.correlator.Correlate(errorGroups)
+		if len(aggResult.Cascades) > 0 {
+			fmt.Printf("🔗 透過 trace 找到 %d 條跨服務連鎖錯誤\n\n", len(aggResult.Cascades))
+		}
+	}
 	result.AggregationResult = aggResult
 
+	if p.config.Volume.Enabled {
+		p.computeErrorRatios(errorGroups, aggResult, result.TimeRange)
+	}
+
 	// Step 4: Analyze
 	fmt.Println("🔍 第 4 步：分析錯誤模式...")
 	analyses := p.createAnalysesFromErrorGroups(errorGroups)
 	fmt.Printf("✅ 從實際數據建立了 %d 個分析結果\n\n", len(analyses))
 	result.Analyses = analyses
 
+	// Aggregate each service on its own for its report
+	serviceResults, err := p.aggregator.AggregateByService(errorGroups, aggResult)
+	if err != nil {
+		return nil, fmt.Errorf("per-service aggregation failed: %w", err)
+	}
+	aggregator.CountHighPriority(aggResult, analyses, errorGroups)
+	for _, serviceResult := range serviceResults {
+		aggregator.CountHighPriority(serviceResult, analyses, errorGroups)
+	}
+	result.ServiceResults = serviceResults
+	for service, serviceResult := range serviceResults {
+		if global, ok := aggResult.ServiceStats[service]; ok {
+			serviceResult.ServiceStats[service].ErrorRatio = global.ErrorRatio
+			serviceResult.ServiceStats[service].BurnRates = global.BurnRates
+		}
+	}
+
+	if !p.config.Analysis.ChangePoints.Disabled {
+		p.detectEpisodes(errorGroups, aggResult, serviceResults)
+	}
+	if err := p.correlateDeploys(errorGroups, aggResult); err != nil {
+		return nil, fmt.Errorf("deploy correlation failed: %w", err)
+	}
+
+	// Runs where nothing parsed would record empty hours and drag baselines down
+	if !p.config.Analysis.Severity.Trend.Disabled && parsedCount > 0 {
+		if err := p.analyzeTrends(errorGroups, analyses, serviceResults, result.TimeRange); err != nil {
+			return nil, fmt.Errorf("trend analysis failed: %w", err)
+		}
+	}
+
 	// Step 5: Generate reports (one per service)
 	fmt.Println("📄 第 5 步：為每個服務生成 Markdown 報告...")
-	if err := p.generatePerServiceReports(analyses, errorGroups, aggResult, result); err != nil {
+	if err := p.generatePerServiceReports(analyses, errorGroups, serviceResults, result); err != nil {
 		return nil, fmt.Errorf("report generation failed: %w", err)
 	}
 	fmt.Println()
 
 	// Step 6: Save JSON
 	fmt.Println("💾 第 6 步：將分析結果保存為 JSON...")
-	if err := reporter.SaveAnalysisJSON(analyses, aggResult, p.config.Output.ReportDir); err != nil {
+	if err := reporter.SaveAnalysisJSON(analyses, errorGroups, aggResult, p.config.Output.ReportDir); err != nil {
 		return nil, fmt.Errorf("saving JSON failed: %w", err)
 	}
 	fmt.Println("✅ 分析 JSON 已保存")
@@ -127,6 +432,234 @@ func (p *Pipeline) Run(timeRangeStr string) (*PipelineResult, error) {
 	return result, nil
 }
 
+// computeErrorRatios fetches each service's total volume and relates errors to it: per group
+// (the severity basis "ratio") and per service with SLO burn rates. When the volume query fails,
+// groups keep no ratio and severity falls back to counts.
+// Error counts come from an aggregation as well, since the log fetch is capped per window:
+// services use the exact counts, groups their fetched counts scaled up to them.
+func (p *Pipeline) computeErrorRatios(groups []models.ErrorGroup, aggResult *interfaces.AggregationResult, window models.TimeRange) {
+	fmt.Println("📏 查詢各服務總量以計算錯誤比率...")
+	services := preprocessor.NewServiceExtractorWithConfig(p.config.Preprocessing.ServiceExtraction)
+	rawVolume, err := p.fetcher.FetchVolume(window.Start, window.End)
+	if err != nil {
+		fmt.Printf("⚠️  總量查詢失敗，嚴重性改以次數判斷：%v\n\n", err)
+		return
+	}
+	volume := normalizeVolumeServices(rawVolume, services)
+
+	var errorVolume map[string][]models.TimeBucket
+	if rawErrors, err := p.fetcher.FetchErrorVolume(window.Start, window.End); err != nil {
+		fmt.Printf("⚠️  錯誤數聚合查詢失敗，改用已獲取的日誌計數（尖峰時可能低估）：%v\n", err)
+	} else {
+		errorVolume = normalizeVolumeServices(rawErrors, services)
+	}
+
+	sloConfig := slo.Config{Target: p.config.Analysis.SLO.Target, MinVolume: p.config.Volume.MinVolume}
+	for _, w := range p.config.Analysis.SLO.Windows {
+		sloConfig.Windows = append(sloConfig.Windows, slo.Window{
+			Long: w.Long, Short: w.Short, BurnRate: w.BurnRate, Severity: models.Severity(w.Severity),
+		})
+	}
+	// Burn-rate windows end on a bucket boundary so the last, partial volume bucket is left out
+	end := window.End.Truncate(p.config.Volume.Interval)
+
+	byService := make(map[string][]models.ErrorGroup)
+	for _, group := range groups {
+		byService[group.ServiceName] = append(byService[group.ServiceName], group)
+	}
+	fetched := make(map[string][]models.TimeBucket, len(byService))
+	for service, serviceGroups := range byService {
+		fetched[service] = trend.MergeSeries(serviceGroups)
+	}
+
+	for i := range groups {
+		series := groups[i].TimeSeries
+		if exact, ok := errorVolume[groups[i].ServiceName]; ok {
+			series = slo.Scale(series, fetched[groups[i].ServiceName], exact, p.config.Volume.Interval)
+		}
+		groups[i].ErrorRatio = slo.Ratio(series, volume[groups[i].ServiceName], sloConfig.MinVolume)
+	}
+	for service := range byService {
+		stats, ok := aggResult.ServiceStats[service]
+		if !ok || len(volume[service]) == 0 {
+			fmt.Printf("⚠️  %s 有錯誤但沒有總量數據，請確認 volume.service_field\n", service)
+			continue
+		}
+		errorSeries, ok := errorVolume[service]
+		if !ok {
+			errorSeries = fetched[service]
+		}
+		stats.ErrorRatio = slo.Ratio(errorSeries, volume[service], sloConfig.MinVolume)
+		stats.BurnRates = slo.BurnRates(errorSeries, volume[service], end, sloConfig)
+		fmt.Printf("   - %s：錯誤比率 %.3f%%（%d / %d）\n", service, stats.ErrorRatio.Ratio*100, stats.ErrorRatio.Errors, stats.ErrorRatio.Total)
+	}
+	fmt.Println()
+}
+
+// normalizeVolumeServices re-keys volume by the service names parsed logs get, summing
+// buckets of raw values that map to the same service; values with no valid name are dropped
+func normalizeVolumeServices(volume map[string][]models.TimeBucket, services *preprocessor.ServiceExtractor) map[string][]models.TimeBucket {
+	counts := make(map[string]map[time.Time]int, len(volume))
+	for raw, series := range volume {
+		service := services.NormalizeServiceName(raw)
+		if service == "" {
+			continue
+		}
+		if counts[service] == nil {
+			counts[service] = make(map[time.Time]int, len(series))
+		}
+		for _, bucket := range series {
+			counts[service][bucket.Start] += bucket.Count
+		}
+	}
+
+	normalized := make(map[string][]models.TimeBucket, len(counts))
+	for service, buckets := range counts {
+		series := make([]models.TimeBucket, 0, len(buckets))
+		for start, count := range buckets {
+			series = append(series, models.TimeBucket{Start: start, Count: count})
+		}
+		sort.Slice(series, func(i, j int) bool {
+			return series[i].Start.Before(series[j].Start)
+		})
+		normalized[service] = series
+	}
+	return normalized
+}
+
+// detectEpisodes finds onset and recovery of rate shifts for each group and each service.
+// Series span the whole run, so a group that only appears during an incident has a zero baseline.
+func (p *Pipeline) detectEpisodes(groups []models.ErrorGroup, aggResult *interfaces.AggregationResult,
+	serviceResults map[string]*interfaces.AggregationResult) {
+	start, end := aggResult.TimeStats.EarliestLogTime, aggResult.TimeStats.LatestLogTime
+	if start.IsZero() {
+		return
+	}
+
+	cpCfg := p.config.Analysis.ChangePoints
+	detector := trend.NewChangePointDetector(normalizationConfig(p.config).BucketSize, trend.ChangePointConfig{
+		Threshold: cpCfg.Threshold,
+		Drift:     cpCfg.Drift,
+		MinCount:  cpCfg.MinCount,
+	})
+
+	byService := make(map[string][]models.ErrorGroup)
+	for i := range groups {
+		groups[i].Episodes = detector.Detect(groups[i].TimeSeries, start, end)
+		byService[groups[i].ServiceName] = append(byService[groups[i].ServiceName], groups[i])
+	}
+	for service, serviceGroups := range byService {
+		episodes := detector.Detect(trend.MergeSeries(serviceGroups), start, end)
+		if stats, ok := aggResult.ServiceStats[service]; ok {
+			stats.Episodes = episodes
+		}
+		if serviceResult, ok := serviceResults[service]; ok {
+			if stats, ok := serviceResult.ServiceStats[service]; ok {
+				stats.Episodes = episodes
+			}
+		}
+	}
+}
+
+// correlateDeploys annotates groups that started shortly after a deploy of their service
+func (p *Pipeline) correlateDeploys(groups []models.ErrorGroup, aggResult *interfaces.AggregationResult) error {
+	events, err := deploy.LoadAll(deploy.NewStore(p.config.Deploys.EventsPath, preprocessor.NewServiceExtractorWithConfig(p.config.Preprocessing.ServiceExtraction)), p.config.Deploys.Files)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	window := time.Duration(p.config.Deploys.WindowMinutes) * time.Minute
+	if annotated := deploy.Annotate(groups, events, window, aggResult.TimeStats.EarliestLogTime); annotated > 0 {
+		fmt.Printf("🚢 %d 個錯誤模式在部署後 %d 分鐘內開始\n\n", annotated, p.config.Deploys.WindowMinutes)
+	}
+	return nil
+}
+
+// analyzeTrends scores groups and services against baselines from previous runs,
+// then records this run's hourly counts into the history
+func (p *Pipeline) analyzeTrends(groups []models.ErrorGroup, analyses []models.Analysis,
+	serviceResults map[string]*interfaces.AggregationResult, window models.TimeRange) error {
+	start, end := window.Start, window.End
+	if err := p.trends.Load(); err != nil {
+		return err
+	}
+
+	trendCfg := p.config.Analysis.Severity.Trend
+	detector := trend.NewDetector(p.trends, trend.Config{
+		AnomalyThreshold: trendCfg.AnomalyThreshold,
+		MinPercentChange: trendCfg.MinPercentChange,
+		MinCount:         trendCfg.MinCount,
+		EWMAAlpha:        trendCfg.EWMAAlpha,
+	})
+
+	// Analyses are created in group order
+	anomalies := 0
+	for i, group := range groups {
+		analyses[i].TrendAnalysis = detector.AnalyzeGroup(group, start, end)
+		if analyses[i].TrendAnalysis != nil && analyses[i].TrendAnalysis.IsAnomalous {
+			anomalies++
+		}
+	}
+	for service, serviceResult := range serviceResults {
+		serviceResult.TrendAnalysis = detector.AnalyzeService(service, serviceResult.TotalLogs, start, end)
+	}
+	if anomalies > 0 {
+		fmt.Printf("📈 %d 個錯誤模式高於歷史基準\n\n", anomalies)
+	}
+
+	p.trends.Record(groups, start, end)
+	return p.trends.Save()
+}
+
+// quarantineRejectedLogs writes rejected raw logs to the dead-letter file and summarizes the reasons
+func (p *Pipeline) quarantineRejectedLogs(procStats preprocessor.ProcessingStats) (*interfaces.ParseFailureStats, error) {
+	writer := preprocessor.NewDeadLetterWriter(p.config.Output.DeadLetterDir)
+	for _, rejected := range p.preprocessor.Rejected() {
+		if err := p.writeRejected(writer, rejected); err != nil {
+			writer.Close()
+			return nil, err
+		}
+	}
+	path, err := writer.Close()
+	if err != nil {
+		return nil, err
+	}
+	return summarizeParseFailures(procStats, path), nil
+}
+
+// writeRejected redacts a rejected log's raw message before it is written to disk
+func (p *Pipeline) writeRejected(writer *preprocessor.DeadLetterWriter, rejected preprocessor.RejectedLog) error {
+	rejected.Error = p.redactor.RedactString(rejected.Error)
+	rejected.RawLog.Source.Message = p.redactor.RedactString(rejected.RawLog.Source.Message)
+	rejected.RawLog.Source.Event.Original = p.redactor.RedactString(rejected.RawLog.Source.Event.Original)
+	return writer.Write(rejected)
+}
+
+// summarizeParseFailures builds the parse failure statistics of a run and prints the reasons
+func summarizeParseFailures(procStats preprocessor.ProcessingStats, deadLetterPath string) *interfaces.ParseFailureStats {
+	failures := &interfaces.ParseFailureStats{
+		TotalRawLogs:   procStats.TotalRawLogs,
+		Failed:         procStats.Failed,
+		Reasons:        make(map[string]int),
+		DeadLetterPath: deadLetterPath,
+	}
+	for reason, count := range procStats.RejectReasons {
+		failures.Reasons[string(reason)] = count
+	}
+
+	if failures.Failed > 0 {
+		fmt.Printf("⚠️  %d 條日誌解析失敗，已寫入：%s\n", failures.Failed, deadLetterPath)
+		for reason, count := range failures.Reasons {
+			fmt.Printf("   - %s: %d 條\n", reason, count)
+		}
+	}
+
+	return failures
+}
+
 // printServiceDistribution prints service distribution from raw logs
 func (p *Pipeline) printServiceDistribution(rawLogs []models.RawLog) {
 	serviceDistribution := make(map[string]int)
@@ -150,12 +683,7 @@ func (p *Pipeline) createAnalysesFromErrorGroups(groups []models.ErrorGroup) []m
 	registry := config.GetRegistry()
 
 	for _, group := range groups {
-		severity := models.SeverityLow
-		if group.TotalCount >= 50 {
-			severity = models.SeverityHigh
-		} else if group.TotalCount >= 10 {
-			severity = models.SeverityMedium
-		}
+		severity := p.severityFor(group)
 
 		// Try to match against known issues
 		var isKnown bool
@@ -167,10 +695,10 @@ func (p *Pipeline) createAnalysesFromErrorGroups(groups []models.ErrorGroup) []m
 		}
 
 		analysis := models.Analysis{
-			ErrorGroupID: group.Fingerprint[:8],
+			ErrorGroupID: models.ShortFingerprint(group.Fingerprint),
 			IsKnown:      isKnown,
 			Severity:     severity,
-			Reason:       fmt.Sprintf("錯誤在服務 %s 中發生了 %d 次", group.ServiceName, group.TotalCount),
+			Reason:       p.severityReason(group),
 			SuggestedActions: []string{
 				fmt.Sprintf("調查錯誤模式：%s", truncateString(group.NormalizedContent, 60)),
 				fmt.Sprintf("檢查來自調用者的日誌：%s", group.CallerFile),
@@ -188,16 +716,70 @@ func (p *Pipeline) createAnalysesFromErrorGroups(groups []models.ErrorGroup) []m
 	return analyses
 }
 
+// severityFor rates a group by its log count, by its peak hourly error ratio when
+// analysis.severity.basis is "ratio" (so traffic peaks do not look like outages), or by its
+// distinct affected entities when the basis names an entity (so a retry loop does not either)
+func (p *Pipeline) severityFor(group models.ErrorGroup) models.Severity {
+	if group.Level == models.LevelFatal {
+		// Fatal/panic-level logs mean a process crashed, regardless of volume
+		return models.SeverityCritical
+	}
+
+	basis := p.config.Analysis.Severity.Basis
+	if basis == "ratio" {
+		// Groups of services without volume data fall back to counts
+		if group.ErrorRatio != nil {
+			thresholds := p.config.Analysis.Severity.RatioThresholds
+			switch {
+			case group.ErrorRatio.PeakRatio >= thresholds.High:
+				return models.SeverityHigh
+			case group.ErrorRatio.PeakRatio >= thresholds.Medium:
+				return models.SeverityMedium
+			}
+			return models.SeverityLow
+		}
+		basis = "count"
+	}
+
+	value, high, medium := group.TotalCount, 50, 10
+	if basis != "" && basis != "count" {
+		thresholds := p.config.Analysis.Severity.EntityThresholds
+		value, high, medium = group.DistinctCounts[basis], thresholds.High, thresholds.Medium
+	}
+
+	switch {
+	case value >= high:
+		return models.SeverityHigh
+	case value >= medium:
+		return models.SeverityMedium
+	}
+	return models.SeverityLow
+}
+
+// severityReason describes what the severity is based on
+func (p *Pipeline) severityReason(group models.ErrorGroup) string {
+	reason := fmt.Sprintf("錯誤在服務 %s 中發生了 %d 次（級別 %s）", group.ServiceName, group.TotalCount, group.Level)
+	switch basis := p.config.Analysis.Severity.Basis; {
+	case basis == "ratio":
+		if group.ErrorRatio != nil {
+			reason += fmt.Sprintf("，錯誤比率 %.2f%%（每小時峰值 %.2f%%）", group.ErrorRatio.Ratio*100, group.ErrorRatio.PeakRatio*100)
+		}
+	case basis != "" && basis != "count":
+		reason += fmt.Sprintf("，影響約 %d 個不同的 %s", group.DistinctCounts[basis], basis)
+	}
+	return reason
+}
+
 // generatePerServiceReports generates reports for each service
 func (p *Pipeline) generatePerServiceReports(analyses []models.Analysis, errorGroups []models.ErrorGroup,
-	aggResult *interfaces.AggregationResult, result *PipelineResult) error {
+	serviceResults map[string]*interfaces.AggregationResult, result *PipelineResult) error {
 
 	// Group analyses by service
 	analysesByService := make(map[string][]models.Analysis)
 	for _, analysis := range analyses {
 		// Find the service for this analysis from errorGroups
 		for _, group := range errorGroups {
-			if group.Fingerprint[:8] == analysis.ErrorGroupID {
+			if models.ShortFingerprint(group.Fingerprint) == analysis.ErrorGroupID {
 				analysesByService[group.ServiceName] = append(analysesByService[group.ServiceName], analysis)
 				break
 			}
@@ -205,8 +787,21 @@ func (p *Pipeline) generatePerServiceReports(analyses []models.Analysis, errorGr
 	}
 
 	// Generate one report per service
+	p.reporter.SetErrorGroups(errorGroups)
+	if len(analysesByService) == 0 {
+		// No error groups (e.g. nothing parsed): still report parse failures in an overall report
+		if failures := result.AggregationResult.ParseFailures; failures != nil && failures.Failed > 0 {
+			report, err := p.reporter.Generate(nil, result.AggregationResult)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✅ 解析失敗報告已生成：%s\n", report.ReportPath)
+			result.Reports["all-services"] = report
+		}
+		return nil
+	}
 	for service, serviceAnalyses := range analysesByService {
-		report, err := p.reporter.GeneratePerService(serviceAnalyses, aggResult, service)
+		report, err := p.reporter.GeneratePerService(serviceAnalyses, serviceResults[service], service)
 		if err != nil {
 			fmt.Printf("❌ 無法生成 %s 的報告：%v\n", service, err)
 			continue
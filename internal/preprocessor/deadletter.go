@@ -0,0 +1,118 @@
+package preprocessor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"log-analyzer/pkg/models"
+)
+
+// RejectReason classifies why a raw log could not be parsed
+type RejectReason string
+
+const (
+	RejectNoMessage       RejectReason = "no_message"
+	RejectWrapperMismatch RejectReason = "wrapper_mismatch"
+	RejectBadJSON         RejectReason = "bad_json"
+	RejectInvalidLevel    RejectReason = "invalid_level"
+	RejectNoService       RejectReason = "no_service"
+	RejectMissingField    RejectReason = "missing_field"
+	RejectBadTimestamp    RejectReason = "bad_timestamp"
+)
+
+// RejectError is returned by processRawLog and carries the rejection reason
+type RejectError struct {
+	Reason RejectReason
+	Err    error
+}
+
+func (e *RejectError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RejectError) Unwrap() error {
+	return e.Err
+}
+
+// reject wraps an error with its rejection reason
+func reject(reason RejectReason, format string, args ...interface{}) *RejectError {
+	return &RejectError{Reason: reason, Err: fmt.Errorf(format, args...)}
+}
+
+// RejectedLog is a raw log that failed preprocessing, as written to the dead-letter file
+type RejectedLog struct {
+	Position int           `json:"position"`
+	Reason   RejectReason  `json:"reason"`
+	Error    string        `json:"error"`
+	RawLog   models.RawLog `json:"raw_log"`
+}
+
+// DeadLetterWriter writes rejected logs as NDJSON (one object per line) as they arrive, so
+// rejects need not be kept in memory. The file is created on the first write.
+type DeadLetterWriter struct {
+	dir     string
+	path    string
+	file    *os.File
+	writer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+// NewDeadLetterWriter creates a writer for a new dead-letter file in dir
+func NewDeadLetterWriter(dir string) *DeadLetterWriter {
+	return &DeadLetterWriter{dir: dir}
+}
+
+// Write appends a rejected log to the dead-letter file
+func (w *DeadLetterWriter) Write(r RejectedLog) error {
+	if w.file == nil {
+		if err := os.MkdirAll(w.dir, 0755); err != nil {
+			return fmt.Errorf("failed to create dead-letter directory: %w", err)
+		}
+
+		filename := fmt.Sprintf("dead-letter_%s.ndjson", time.Now().Format("2006-01-02_15-04-05"))
+		path := filepath.Join(w.dir, filename)
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create dead-letter file: %w", err)
+		}
+		w.path = path
+		w.file = file
+		w.writer = bufio.NewWriter(file)
+		w.encoder = json.NewEncoder(w.writer)
+	}
+
+	if err := w.encoder.Encode(r); err != nil {
+		return fmt.Errorf("failed to write dead-letter entry: %w", err)
+	}
+	return nil
+}
+
+// Close flushes and closes the file and returns its path; empty when nothing was written
+func (w *DeadLetterWriter) Close() (string, error) {
+	if w.file == nil {
+		return "", nil
+	}
+	defer w.file.Close()
+
+	if err := w.writer.Flush(); err != nil {
+		return "", fmt.Errorf("failed to flush dead-letter file: %w", err)
+	}
+	return w.path, nil
+}
+
+// WriteDeadLetter writes rejected logs as NDJSON (one object per line) into dir
+// and returns the path of the created file. Nothing is written when rejected is empty.
+func WriteDeadLetter(dir string, rejected []RejectedLog) (string, error) {
+	writer := NewDeadLetterWriter(dir)
+	for _, r := range rejected {
+		if err := writer.Write(r); err != nil {
+			writer.Close()
+			return "", err
+		}
+	}
+	return writer.Close()
+}
@@ -0,0 +1,150 @@
+package preprocessor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// decodeInnerJSON decodes the inner log payload into InnerLogData.
+//
+// The payload may be:
+//   - a plain JSON object: {"content":"..."}
+//   - a JSON string literal wrapping an object: "{\"content\":\"...\"}"
+//   - an object whose quotes were escaped once: {\"content\":\"...\"}
+//
+// Exactly one layer of escaping is removed when needed, and anything after the
+// first complete JSON value (e.g. a trailing newline fragment) is ignored.
+func decodeInnerJSON(payload string) (*InnerLogData, error) {
+	trimmed := strings.TrimSpace(payload)
+	if trimmed == "" {
+		return nil, fmt.Errorf("empty payload")
+	}
+
+	switch {
+	case trimmed[0] == '"':
+		// String literal wrapping JSON: unescape one layer
+		var unwrapped string
+		if err := decodeFirstValue(trimmed, &unwrapped); err != nil {
+			return nil, fmt.Errorf("failed to decode string literal: %w", err)
+		}
+		return decodeObject(strings.TrimSpace(unwrapped))
+
+	case strings.HasPrefix(trimmed, `{\"`):
+		// Object with escaped quotes but without the enclosing string literal
+		unwrapped, err := unescapeLayer(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unescape payload: %w", err)
+		}
+		return decodeObject(strings.TrimSpace(unwrapped))
+
+	default:
+		return decodeObject(trimmed)
+	}
+}
+
+// decodeObject decodes a JSON object, ignoring trailing data after it
+func decodeObject(s string) (*InnerLogData, error) {
+	if !strings.HasPrefix(s, "{") {
+		return nil, fmt.Errorf("payload is not a JSON object")
+	}
+
+	var innerLog InnerLogData
+	if err := decodeFirstValue(s, &innerLog); err != nil {
+		return nil, err
+	}
+	return &innerLog, nil
+}
+
+// decodeFirstValue decodes the first JSON value in s into v
+func decodeFirstValue(s string, v interface{}) error {
+	decoder := json.NewDecoder(strings.NewReader(s))
+	return decoder.Decode(v)
+}
+
+// unescapeLayer removes one layer of JSON string escaping from s.
+// Trailing data after the escaped object is dropped before unescaping.
+func unescapeLayer(s string) (string, error) {
+	end := escapedObjectEnd(s)
+	if end > 0 {
+		s = s[:end]
+	}
+
+	var unescaped string
+	if err := json.Unmarshal([]byte(`"`+s+`"`), &unescaped); err != nil {
+		return "", err
+	}
+	return unescaped, nil
+}
+
+// escapedObjectEnd finds the end of an object encoded with one layer of escaping.
+// Each escape sequence is unescaped on the fly and the resulting characters are fed
+// through a minimal JSON state machine. It returns 0 when no closing brace is found.
+func escapedObjectEnd(s string) int {
+	depth := 0
+	inString := false
+	innerEscape := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' {
+			if i+1 >= len(s) {
+				return 0
+			}
+			// Only \\ and \" matter structurally; other escapes (\n, \t) are plain characters
+			i++
+			c = s[i]
+		}
+
+		if inString {
+			switch {
+			case innerEscape:
+				innerEscape = false
+			case c == '\\':
+				innerEscape = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+
+	return 0
+}
+
+// logContent is the content field of an inner log.
+// Strings are decoded normally; structured content (objects, arrays) is kept verbatim.
+type logContent string
+
+// UnmarshalJSON keeps non-string content as its raw JSON text
+func (c *logContent) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*c = logContent(s)
+		return nil
+	}
+
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*c = ""
+		return nil
+	}
+
+	*c = logContent(data)
+	return nil
+}
@@ -0,0 +1,109 @@
+package preprocessor
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"log-analyzer/pkg/models"
+)
+
+// defaultLevelAliases maps common source level names to canonical levels
+var defaultLevelAliases = map[string]models.LogLevel{
+	"trace":       models.LevelTrace,
+	"verbose":     models.LevelTrace,
+	"debug":       models.LevelDebug,
+	"dbg":         models.LevelDebug,
+	"info":        models.LevelInfo,
+	"information": models.LevelInfo,
+	"notice":      models.LevelInfo,
+	"warn":        models.LevelWarn,
+	"warning":     models.LevelWarn,
+	"error":       models.LevelError,
+	"err":         models.LevelError,
+	"dpanic":      models.LevelError, // zap development panic: logged as error in production
+	"fatal":       models.LevelFatal,
+	"panic":       models.LevelFatal,
+	"critical":    models.LevelFatal,
+	"crit":        models.LevelFatal,
+	"alert":       models.LevelFatal,
+	"emerg":       models.LevelFatal,
+	"emergency":   models.LevelFatal,
+}
+
+// LevelMapper maps source log levels to canonical levels
+type LevelMapper struct {
+	aliases map[string]models.LogLevel
+}
+
+// NewLevelMapper creates a level mapper with the built-in aliases plus extra ones.
+// Extra aliases override built-in ones; aliases targeting unknown levels are ignored.
+func NewLevelMapper(extra map[string]string) *LevelMapper {
+	aliases := make(map[string]models.LogLevel, len(defaultLevelAliases)+len(extra))
+	for alias, level := range defaultLevelAliases {
+		aliases[alias] = level
+	}
+	for alias, level := range extra {
+		canonical := models.LogLevel(strings.ToLower(level))
+		if canonical.IsValid() {
+			aliases[strings.ToLower(strings.TrimSpace(alias))] = canonical
+		}
+	}
+
+	return &LevelMapper{aliases: aliases}
+}
+
+// Map returns the canonical level for a source level.
+// Numeric bunyan/pino levels (10 trace ... 60 fatal) are supported.
+func (m *LevelMapper) Map(level string) (models.LogLevel, bool) {
+	key := strings.ToLower(strings.TrimSpace(level))
+	if canonical, ok := m.aliases[key]; ok {
+		return canonical, true
+	}
+
+	if n, err := strconv.Atoi(key); err == nil {
+		return numericLevel(n)
+	}
+
+	return "", false
+}
+
+// numericLevel maps bunyan/pino numeric levels (10-60, custom levels in between round up)
+// to canonical levels. Other numbers (syslog 0-7, HTTP statuses) are not levels.
+func numericLevel(n int) (models.LogLevel, bool) {
+	switch {
+	case n < 10 || n > 60:
+		return "", false
+	case n <= 10:
+		return models.LevelTrace, true
+	case n <= 20:
+		return models.LevelDebug, true
+	case n <= 30:
+		return models.LevelInfo, true
+	case n <= 40:
+		return models.LevelWarn, true
+	case n <= 50:
+		return models.LevelError, true
+	default:
+		return models.LevelFatal, true
+	}
+}
+
+// sourceLevel is a level field that may be encoded as a JSON string or number
+type sourceLevel string
+
+// UnmarshalJSON accepts both "error" and 50 style levels
+func (l *sourceLevel) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*l = sourceLevel(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*l = sourceLevel(n.String())
+	return nil
+}
@@ -0,0 +1,92 @@
+package preprocessor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"log-analyzer/pkg/models"
+)
+
+// defaultChunkSize is the number of raw logs handed to a worker at once
+const defaultChunkSize = 1000
+
+// Throughput describes how the last Process call was executed
+type Throughput struct {
+	Workers  int
+	Chunks   int
+	Duration time.Duration
+}
+
+// chunkResult holds the output of one chunk, in input order
+type chunkResult struct {
+	parsed   []models.ParsedLog
+	rejected []RejectedLog
+}
+
+// processChunks splits raw logs into chunks and parses them on a worker pool.
+// Results are indexed by chunk so the caller can merge them in input order.
+// The preprocessor's regexes, level mapper and service extractor are read-only
+// and shared by all workers.
+func (p *LogPreprocessor) processChunks(rawLogs []models.RawLog) []chunkResult {
+	numChunks := (len(rawLogs) + p.chunkSize - 1) / p.chunkSize
+	results := make([]chunkResult, numChunks)
+
+	workers := p.workers
+	if workers > numChunks {
+		workers = numChunks
+	}
+
+	chunks := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range chunks {
+				start := idx * p.chunkSize
+				end := start + p.chunkSize
+				if end > len(rawLogs) {
+					end = len(rawLogs)
+				}
+				results[idx] = p.processChunk(rawLogs[start:end], start)
+			}
+		}()
+	}
+
+	for idx := 0; idx < numChunks; idx++ {
+		chunks <- idx
+	}
+	close(chunks)
+	wg.Wait()
+
+	return results
+}
+
+// processChunk parses one chunk sequentially; offset is the position of its first log
+func (p *LogPreprocessor) processChunk(rawLogs []models.RawLog, offset int) chunkResult {
+	result := chunkResult{
+		parsed: make([]models.ParsedLog, 0, len(rawLogs)),
+	}
+
+	for i, rawLog := range rawLogs {
+		parsedLog, err := p.processRawLog(rawLog)
+		if err != nil {
+			// Quarantine the log but continue processing other logs
+			result.rejected = append(result.rejected, RejectedLog{
+				Position: offset + i,
+				Reason:   rejectReasonOf(err),
+				Error:    fmt.Sprintf("failed to process log %d: %v", offset+i, err),
+				RawLog:   rawLog,
+			})
+			continue
+		}
+
+		if parsedLog != nil {
+			result.parsed = append(result.parsed, *parsedLog)
+		}
+	}
+
+	return result
+}
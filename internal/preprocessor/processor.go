@@ -1,56 +1,104 @@
 package preprocessor
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
+	"runtime"
 	"strings"
 	"time"
 
+	"log-analyzer/internal/config"
 	"log-analyzer/pkg/models"
 )
 
 // LogPreprocessor implements the Preprocessor interface
 type LogPreprocessor struct {
 	wrapperRegex *regexp.Regexp
+	levels       *LevelMapper
+	services     *ServiceExtractor
+	timestamps   *TimestampResolver
+	workers      int
+	chunkSize    int
+	rejected     []RejectedLog
+	throughput   Throughput
 }
 
 // NewLogPreprocessor creates a new log preprocessor
 func NewLogPreprocessor() *LogPreprocessor {
-	// Regex to match Kubernetes wrapper format: "TIMESTAMP stderr F JSON_CONTENT"
-	wrapperRegex := regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d+Z\s+stderr\s+F\s+(.*)$`)
+	return NewLogPreprocessorWithConfig(config.PreprocessingConfig{})
+}
+
+// NewLogPreprocessorWithConfig creates a log preprocessor using the preprocessing settings
+func NewLogPreprocessorWithConfig(cfg config.PreprocessingConfig) *LogPreprocessor {
+	// Regex to match Kubernetes (CRI) wrapper format: "TIMESTAMP stderr F JSON_CONTENT"
+	wrapperRegex := regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2}))\s+(?:stdout|stderr)\s+[FP]\s+(.*)$`)
+
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	chunkSize := cfg.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
 
 	return &LogPreprocessor{
 		wrapperRegex: wrapperRegex,
+		levels:       NewLevelMapper(cfg.LevelAliases),
+		services:     NewServiceExtractorWithConfig(cfg.ServiceExtraction),
+		timestamps:   NewTimestampResolver(cfg.Timestamps),
+		workers:      workers,
+		chunkSize:    chunkSize,
 	}
 }
 
-// Process processes raw logs and extracts structured data
+// Process processes raw logs and extracts structured data.
+// Logs are parsed concurrently in chunks; the output keeps the input order.
+// Logs that fail to parse are kept and can be retrieved with Rejected.
 func (p *LogPreprocessor) Process(rawLogs []models.RawLog) ([]models.ParsedLog, error) {
-	var parsedLogs []models.ParsedLog
-	var processingErrors []error
-
-	for i, rawLog := range rawLogs {
-		parsedLog, err := p.processRawLog(rawLog)
-		if err != nil {
-			// Log the error but continue processing other logs
-			processingErrors = append(processingErrors, fmt.Errorf("failed to process log %d: %w", i, err))
-			continue
-		}
+	startTime := time.Now()
 
-		if parsedLog != nil {
-			parsedLogs = append(parsedLogs, *parsedLog)
-		}
+	results := p.processChunks(rawLogs)
+
+	// Merge chunk results in input order
+	parsedLogs := make([]models.ParsedLog, 0, len(rawLogs))
+	p.rejected = nil
+	for _, result := range results {
+		parsedLogs = append(parsedLogs, result.parsed...)
+		p.rejected = append(p.rejected, result.rejected...)
+	}
+
+	p.throughput = Throughput{
+		Workers:  p.workers,
+		Chunks:   len(results),
+		Duration: time.Since(startTime),
 	}
 
 	// Return results even if some logs failed to process (graceful degradation)
-	if len(parsedLogs) == 0 && len(processingErrors) > 0 {
-		return nil, fmt.Errorf("failed to process any logs: %v", processingErrors)
+	if len(parsedLogs) == 0 && len(p.rejected) > 0 {
+		first := p.rejected[0]
+		return nil, fmt.Errorf("failed to process any logs: %d rejected, first at %d: %s",
+			len(p.rejected), first.Position, first.Error)
 	}
 
 	return parsedLogs, nil
 }
 
+// Rejected returns the logs rejected by the last call to Process
+func (p *LogPreprocessor) Rejected() []RejectedLog {
+	return p.rejected
+}
+
+// rejectReasonOf extracts the rejection reason from a processing error
+func rejectReasonOf(err error) RejectReason {
+	var rejectErr *RejectError
+	if errors.As(err, &rejectErr) {
+		return rejectErr.Reason
+	}
+	return RejectMissingField
+}
+
 // processRawLog processes a single raw log entry
 func (p *LogPreprocessor) processRawLog(rawLog models.RawLog) (*models.ParsedLog, error) {
 	// Extract the message content from the raw log
@@ -62,50 +110,77 @@ func (p *LogPreprocessor) processRawLog(rawLog models.RawLog) (*models.ParsedLog
 	} else if rawLog.Source.Event.Original != "" {
 		messageContent = rawLog.Source.Event.Original
 	} else {
-		return nil, fmt.Errorf("no message content found in raw log")
+		return nil, reject(RejectNoMessage, "no message content found in raw log")
 	}
 
 	// Remove Kubernetes wrapper if present
-	innerJSON, err := p.removeWrapper(messageContent)
+	innerJSON, wrapperTime, err := p.splitWrapper(messageContent)
 	if err != nil {
-		return nil, fmt.Errorf("failed to remove wrapper: %w", err)
+		return nil, reject(RejectWrapperMismatch, "failed to remove wrapper: %w", err)
 	}
 
 	// Parse the inner JSON
 	innerLog, err := p.parseInnerJSON(innerJSON)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse inner JSON: %w", err)
+		return nil, reject(RejectBadJSON, "failed to parse inner JSON: %w", err)
 	}
 
-	// Extract service name from the raw log using ServiceExtractor
-	extractor := NewServiceExtractor()
-	serviceName, err := extractor.ExtractServiceName(rawLog)
+	// Extract service name and workload location using the configured rules
+	serviceName, err := p.services.ExtractServiceName(rawLog)
 	if err != nil {
-		// Fallback: try to get from Fields.ServiceName
-		serviceName = rawLog.Source.Fields.ServiceName
-		if serviceName == "" {
-			// Last resort: extract from rawLog index name
-			serviceName = extractServiceFromIndex(rawLog.Index)
-			if serviceName == "" {
-				return nil, fmt.Errorf("unable to extract service name from log")
-			}
-		}
+		return nil, reject(RejectNoService, "unable to extract service name from log: %w", err)
+	}
+	k8s := p.services.ExtractKubernetesMetadata(rawLog)
+
+	// Map the source level to a canonical level
+	originalLevel := string(innerLog.Level)
+	level, ok := p.levels.Map(originalLevel)
+	if !ok && originalLevel != "" {
+		return nil, reject(RejectInvalidLevel, "invalid log level: %s", originalLevel)
+	}
+
+	// Resolve the timestamp from the configured sources; an unparseable inner
+	// timestamp counts as missing so the wrapper or document time can be used
+	innerTime, innerErr := p.timestamps.Parse(string(innerLog.Timestamp))
+	timestamp, timestampSource := p.timestamps.Resolve(map[string]time.Time{
+		TimestampInner:   innerTime,
+		TimestampWrapper: wrapperTime,
+		TimestampSource:  rawLog.Source.Timestamp,
+	})
+	if timestamp.IsZero() && innerErr != nil {
+		return nil, reject(RejectBadTimestamp, "invalid inner timestamp: %w", innerErr)
 	}
 
 	// Create parsed log
 	parsedLog := &models.ParsedLog{
-		Timestamp:   innerLog.Timestamp,
-		Caller:      innerLog.Caller,
-		Content:     innerLog.Content,
-		Level:       innerLog.Level,
-		Span:        innerLog.Span,
-		Trace:       innerLog.Trace,
-		ServiceName: serviceName,
+		Timestamp:       timestamp,
+		TimestampSource: timestampSource,
+		Caller:          innerLog.Caller,
+		Content:         string(innerLog.Content),
+		Level:           level,
+		OriginalLevel:   originalLevel,
+		Span:            innerLog.Span,
+		Trace:           innerLog.Trace,
+		ServiceName:     serviceName,
+		Namespace:       k8s.Namespace,
+		Pod:             k8s.Pod,
+		Container:       k8s.Container,
+		Node:            k8s.Node,
+	}
+
+	// Record skew between the application clock and the runtime/shipper clock
+	outerTime := wrapperTime
+	if outerTime.IsZero() {
+		outerTime = rawLog.Source.Timestamp
+	}
+	if !innerTime.IsZero() && !outerTime.IsZero() {
+		skew := innerTime.Sub(outerTime)
+		parsedLog.ClockSkew = &skew
 	}
 
 	// Validate required fields
 	if err := p.validateParsedLog(parsedLog); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+		return nil, &RejectError{Reason: rejectReasonOf(err), Err: fmt.Errorf("validation failed: %w", err)}
 	}
 
 	return parsedLog, nil
@@ -113,73 +188,70 @@ func (p *LogPreprocessor) processRawLog(rawLog models.RawLog) (*models.ParsedLog
 
 // removeWrapper removes the Kubernetes wrapper and extracts the inner JSON
 func (p *LogPreprocessor) removeWrapper(message string) (string, error) {
+	payload, _, err := p.splitWrapper(message)
+	return payload, err
+}
+
+// splitWrapper splits a message into the inner JSON and the wrapper timestamp (zero if no wrapper)
+func (p *LogPreprocessor) splitWrapper(message string) (string, time.Time, error) {
 	// Check if the message has the Kubernetes wrapper format
 	matches := p.wrapperRegex.FindStringSubmatch(message)
-	if len(matches) >= 2 {
-		// Extract the JSON part (group 1)
-		return matches[1], nil
+	if len(matches) >= 3 {
+		// Group 1 is the runtime timestamp, group 2 the JSON part
+		wrapperTime, _ := time.Parse(time.RFC3339Nano, matches[1])
+		return matches[2], wrapperTime, nil
 	}
 
 	// If no wrapper found, assume the message is already clean JSON
 	// This handles cases where logs might not have the wrapper
 	if strings.HasPrefix(strings.TrimSpace(message), "{") {
-		return message, nil
+		return message, time.Time{}, nil
 	}
 
-	return "", fmt.Errorf("message does not match expected format: %s", message[:min(100, len(message))])
+	return "", time.Time{}, fmt.Errorf("message does not match expected format: %s", message[:min(100, len(message))])
 }
 
 // InnerLogData represents the structure of the inner JSON log
 type InnerLogData struct {
-	Timestamp time.Time `json:"@timestamp"`
-	Caller    string    `json:"caller"`
-	Content   string    `json:"content"`
-	Level     string    `json:"level"`
-	Span      string    `json:"span"`
-	Trace     string    `json:"trace"`
+	Timestamp sourceTime  `json:"@timestamp"`
+	Caller    string      `json:"caller"`
+	Content   logContent  `json:"content"`
+	Level     sourceLevel `json:"level"`
+	Span      string      `json:"span"`
+	Trace     string      `json:"trace"`
 }
 
-// parseInnerJSON parses the inner JSON content
+// parseInnerJSON parses the inner JSON content, unescaping double-encoded payloads
 func (p *LogPreprocessor) parseInnerJSON(jsonStr string) (*InnerLogData, error) {
-	// Clean up the JSON string (handle escaped quotes)
-	cleanJSON := strings.ReplaceAll(jsonStr, `\"`, `"`)
-
-	var innerLog InnerLogData
-	if err := json.Unmarshal([]byte(cleanJSON), &innerLog); err != nil {
+	innerLog, err := decodeInnerJSON(jsonStr)
+	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal JSON: %w, content: %s", err, jsonStr[:min(200, len(jsonStr))])
 	}
 
-	return &innerLog, nil
+	return innerLog, nil
 }
 
 // validateParsedLog validates that the parsed log has required fields
 func (p *LogPreprocessor) validateParsedLog(log *models.ParsedLog) error {
 	if log.Timestamp.IsZero() {
-		return fmt.Errorf("timestamp is required")
+		return reject(RejectMissingField, "timestamp is required")
 	}
 
 	if log.Content == "" {
-		return fmt.Errorf("content is required")
+		return reject(RejectMissingField, "content is required")
 	}
 
 	if log.Level == "" {
-		return fmt.Errorf("level is required")
+		return reject(RejectInvalidLevel, "level is required")
 	}
 
 	if log.ServiceName == "" {
-		return fmt.Errorf("service name is required")
-	}
-
-	// Validate log level
-	validLevels := map[string]bool{
-		"error": true,
-		"warn":  true,
-		"info":  true,
-		"debug": true,
+		return reject(RejectNoService, "service name is required")
 	}
 
-	if !validLevels[strings.ToLower(log.Level)] {
-		return fmt.Errorf("invalid log level: %s", log.Level)
+	// Validate log level (must already be canonical)
+	if !log.Level.IsValid() {
+		return reject(RejectInvalidLevel, "invalid log level: %s", log.Level)
 	}
 
 	return nil
@@ -200,7 +272,21 @@ func (p *LogPreprocessor) GetProcessingStats(rawLogs []models.RawLog, parsedLogs
 	// Count by log level
 	stats.LevelCounts = make(map[string]int)
 	for _, log := range parsedLogs {
-		stats.LevelCounts[log.Level]++
+		stats.LevelCounts[string(log.Level)]++
+	}
+
+	// Throughput of the last Process call
+	stats.Workers = p.throughput.Workers
+	stats.Chunks = p.throughput.Chunks
+	stats.DurationMs = p.throughput.Duration.Milliseconds()
+	if seconds := p.throughput.Duration.Seconds(); seconds > 0 {
+		stats.LogsPerSecond = float64(len(rawLogs)) / seconds
+	}
+
+	// Count rejections by reason
+	stats.RejectReasons = make(map[RejectReason]int)
+	for _, r := range p.rejected {
+		stats.RejectReasons[r.Reason]++
 	}
 
 	return stats
@@ -225,11 +311,16 @@ func extractServiceFromIndex(indexName string) string {
 
 // ProcessingStats contains statistics about the preprocessing operation
 type ProcessingStats struct {
-	TotalRawLogs       int            `json:"total_raw_logs"`
-	SuccessfullyParsed int            `json:"successfully_parsed"`
-	Failed             int            `json:"failed"`
-	SuccessRate        float64        `json:"success_rate"`
-	LevelCounts        map[string]int `json:"level_counts"`
+	TotalRawLogs       int                  `json:"total_raw_logs"`
+	SuccessfullyParsed int                  `json:"successfully_parsed"`
+	Failed             int                  `json:"failed"`
+	SuccessRate        float64              `json:"success_rate"`
+	LevelCounts        map[string]int       `json:"level_counts"`
+	RejectReasons      map[RejectReason]int `json:"reject_reasons"`
+	Workers            int                  `json:"workers"`
+	Chunks             int                  `json:"chunks"`
+	DurationMs         int64                `json:"duration_ms"`
+	LogsPerSecond      float64              `json:"logs_per_second"`
 }
 
 // min returns the minimum of two integers
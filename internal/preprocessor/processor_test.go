@@ -1,9 +1,13 @@
 package preprocessor
 
 import (
+	"fmt"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"log-analyzer/internal/config"
 	"log-analyzer/pkg/models"
 )
 
@@ -154,8 +158,293 @@ func TestProcessRawLog(t *testing.T) {
 	}
 }
 
+func TestProcessQuarantinesRejectedLogs(t *testing.T) {
+	processor := NewLogPreprocessor()
+
+	wrap := func(inner string) string {
+		return `2026-01-10T11:30:32.804760259Z stderr F ` + inner
+	}
+	fields := models.FieldsData{ServiceName: "test-service"}
+
+	rawLogs := []models.RawLog{
+		{ID: "ok", Source: models.OpenSearchSource{Fields: fields,
+			Message: wrap(`{"@timestamp":"2026-01-10T19:30:32.804+08:00","content":"boom","level":"error"}`)}},
+		{ID: "empty", Source: models.OpenSearchSource{Fields: fields}},
+		{ID: "plain", Source: models.OpenSearchSource{Fields: fields, Message: "plain text line"}},
+		{ID: "json", Source: models.OpenSearchSource{Fields: fields, Message: wrap(`{"content":`)}},
+		{ID: "level", Source: models.OpenSearchSource{Fields: fields,
+			Message: wrap(`{"@timestamp":"2026-01-10T19:30:32.804+08:00","content":"boom","level":"chatty"}`)}},
+	}
+
+	parsed, err := processor.Process(rawLogs)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(parsed) != 1 {
+		t.Fatalf("Expected 1 parsed log, got %d", len(parsed))
+	}
+
+	expected := map[string]RejectReason{
+		"empty": RejectNoMessage,
+		"plain": RejectWrapperMismatch,
+		"json":  RejectBadJSON,
+		"level": RejectInvalidLevel,
+	}
+
+	rejected := processor.Rejected()
+	if len(rejected) != len(expected) {
+		t.Fatalf("Expected %d rejected logs, got %d", len(expected), len(rejected))
+	}
+	for _, r := range rejected {
+		if want := expected[r.RawLog.ID]; r.Reason != want {
+			t.Errorf("Log %s: expected reason %s, got %s", r.RawLog.ID, want, r.Reason)
+		}
+	}
+
+	stats := processor.GetProcessingStats(rawLogs, parsed)
+	if stats.Failed != 4 || stats.RejectReasons[RejectBadJSON] != 1 {
+		t.Errorf("Unexpected processing stats: %+v", stats)
+	}
+}
+
+func TestWriteDeadLetter(t *testing.T) {
+	dir := t.TempDir()
+
+	path, err := WriteDeadLetter(dir, []RejectedLog{
+		{Position: 0, Reason: RejectBadJSON, Error: "bad", RawLog: models.RawLog{ID: "a"}},
+		{Position: 3, Reason: RejectNoMessage, Error: "empty", RawLog: models.RawLog{ID: "b"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read dead-letter file: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("Expected 2 NDJSON lines, got %d", lines)
+	}
+
+	if path, err := WriteDeadLetter(dir, nil); err != nil || path != "" {
+		t.Errorf("Expected no file for empty input, got %q, %v", path, err)
+	}
+}
+
+func TestLevelMapper(t *testing.T) {
+	mapper := NewLevelMapper(map[string]string{"severe": "FATAL", "bogus": "nope"})
+
+	tests := []struct {
+		input    string
+		expected models.LogLevel
+		ok       bool
+	}{
+		{"ERROR", models.LevelError, true},
+		{"ERR", models.LevelError, true},
+		{"warning", models.LevelWarn, true},
+		{"panic", models.LevelFatal, true},
+		{"critical", models.LevelFatal, true},
+		{"dpanic", models.LevelError, true},
+		{"severe", models.LevelFatal, true},
+		{"30", models.LevelInfo, true},
+		{"50", models.LevelError, true},
+		{"60", models.LevelFatal, true},
+		{"3", "", false},
+		{"404", "", false},
+		{"500", "", false},
+		{"bogus", "", false},
+	}
+
+	for _, tt := range tests {
+		level, ok := mapper.Map(tt.input)
+		if ok != tt.ok || level != tt.expected {
+			t.Errorf("Map(%q) = %q, %v; want %q, %v", tt.input, level, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestProcessRawLogKeepsOriginalLevel(t *testing.T) {
+	processor := NewLogPreprocessor()
+
+	rawLog := models.RawLog{
+		Source: models.OpenSearchSource{
+			Message: `{"@timestamp":"2026-01-10T19:30:32.804+08:00","content":"crashed","level":60}`,
+			Fields:  models.FieldsData{ServiceName: "test-service"},
+		},
+	}
+
+	result, err := processor.processRawLog(rawLog)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Level != models.LevelFatal || result.OriginalLevel != "60" {
+		t.Errorf("Expected fatal level from original 60, got %q from %q", result.Level, result.OriginalLevel)
+	}
+}
+
+func TestProcessPreservesOrderAcrossWorkers(t *testing.T) {
+	processor := NewLogPreprocessorWithConfig(config.PreprocessingConfig{Workers: 4, ChunkSize: 7})
+
+	var rawLogs []models.RawLog
+	for i := 0; i < 100; i++ {
+		message := fmt.Sprintf(`{"@timestamp":"2026-01-10T19:30:32.804+08:00","content":"error %d","level":"error"}`, i)
+		if i%10 == 3 {
+			message = "broken"
+		}
+		rawLogs = append(rawLogs, models.RawLog{Source: models.OpenSearchSource{
+			Message: message,
+			Fields:  models.FieldsData{ServiceName: "test-service"},
+		}})
+	}
+
+	parsed, err := processor.Process(rawLogs)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(parsed) != 90 {
+		t.Fatalf("Expected 90 parsed logs, got %d", len(parsed))
+	}
+
+	expected := 0
+	for _, log := range parsed {
+		if expected%10 == 3 {
+			expected++
+		}
+		if want := fmt.Sprintf("error %d", expected); log.Content != want {
+			t.Fatalf("Expected %q, got %q", want, log.Content)
+		}
+		expected++
+	}
+
+	for i, r := range processor.Rejected() {
+		if r.Position != i*10+3 {
+			t.Errorf("Rejected log %d: expected position %d, got %d", i, i*10+3, r.Position)
+		}
+	}
+
+	stats := processor.GetProcessingStats(rawLogs, parsed)
+	if stats.Workers != 4 || stats.Chunks != 15 {
+		t.Errorf("Expected 4 workers and 15 chunks, got %d and %d", stats.Workers, stats.Chunks)
+	}
+}
+
+func TestProcessStream(t *testing.T) {
+	processor := NewLogPreprocessorWithConfig(config.PreprocessingConfig{Workers: 3})
+
+	in := make(chan models.RawLog)
+	out := make(chan models.ParsedLog)
+	go func() {
+		for i := 0; i < 50; i++ {
+			message := fmt.Sprintf(`{"@timestamp":"2026-01-10T19:30:32.804+08:00","content":"error %d","level":"error"}`, i)
+			if i%10 == 3 {
+				message = "broken"
+			}
+			in <- models.RawLog{Source: models.OpenSearchSource{
+				Message: message,
+				Fields:  models.FieldsData{ServiceName: "test-service"},
+			}}
+		}
+		close(in)
+	}()
+
+	deadLetter := NewDeadLetterWriter(t.TempDir())
+	var stats ProcessingStats
+	done := make(chan struct{})
+	go func() {
+		stats = processor.ProcessStream(in, out, func(r RejectedLog) {
+			if err := deadLetter.Write(r); err != nil {
+				t.Errorf("dead-letter write failed: %v", err)
+			}
+		})
+		close(done)
+	}()
+
+	seen := make(map[string]bool)
+	for log := range out {
+		seen[log.Content] = true
+	}
+	<-done
+
+	if len(seen) != 45 {
+		t.Fatalf("Expected 45 distinct parsed logs, got %d", len(seen))
+	}
+	if stats.TotalRawLogs != 50 || stats.SuccessfullyParsed != 45 || stats.Failed != 5 {
+		t.Errorf("Unexpected stream stats: %+v", stats)
+	}
+	if stats.RejectReasons[RejectWrapperMismatch]+stats.RejectReasons[RejectBadJSON] != 5 {
+		t.Errorf("Expected 5 rejected logs, got %v", stats.RejectReasons)
+	}
+	// Rejects are streamed to the dead-letter file, not kept in memory
+	if len(processor.Rejected()) != 0 {
+		t.Errorf("Expected no rejects kept in memory, got %d", len(processor.Rejected()))
+	}
+	path, err := deadLetter.Close()
+	if err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read dead-letter file: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 5 {
+		t.Errorf("Expected 5 dead-letter entries, got %d", lines)
+	}
+}
+
 // Placeholder for property-based tests that will be implemented in subtasks
 func TestPreprocessorPropertyBased(t *testing.T) {
 	// Property-based tests will be implemented in tasks 3.1, 3.2, 3.3
 	t.Skip("Property-based tests will be implemented in subtasks")
 }
+
+func TestParseInnerJSONEncodings(t *testing.T) {
+	processor := NewLogPreprocessor()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Escaped quotes inside content are preserved",
+			input:    `{"content":"bad request body: {\"gameId\":\"vs20\"}","level":"error"}`,
+			expected: `bad request body: {"gameId":"vs20"}`,
+		},
+		{
+			name:     "String literal wrapping JSON",
+			input:    `"{\"content\":\"quoted \\\"id\\\" missing\",\"level\":\"error\"}"`,
+			expected: `quoted "id" missing`,
+		},
+		{
+			name:     "Escaped object without enclosing quotes",
+			input:    `{\"content\":\"path C:\\\\tmp \\\"x\\\"\",\"level\":\"error\"} trailing`,
+			expected: `path C:\tmp "x"`,
+		},
+		{
+			name:     "Trailing garbage after object",
+			input:    `{"content":"boom","level":"error"}}\n`,
+			expected: `boom`,
+		},
+		{
+			name:     "Structured content kept verbatim",
+			input:    `{"content":{"code":500, "msg":"x"},"level":"error"}`,
+			expected: `{"code":500, "msg":"x"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := processor.parseInnerJSON(tt.input)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(result.Content) != tt.expected {
+				t.Errorf("Expected content %q, got %q", tt.expected, result.Content)
+			}
+		})
+	}
+
+	if _, err := processor.parseInnerJSON(`not json`); err == nil {
+		t.Error("Expected error for non-JSON payload")
+	}
+}
@@ -5,83 +5,248 @@ import (
 	"regexp"
 	"strings"
 
+	"log-analyzer/internal/config"
 	"log-analyzer/pkg/models"
 )
 
+// Default extraction rules, used for any rule list left empty in the configuration
+var (
+	// defaultServiceSources lists the raw log fields to try, in order
+	defaultServiceSources = []string{
+		"fields.servicename",
+		"kubernetes.labels.app",
+		"kubernetes.container.name",
+		"log.file.path",
+		"host.name",
+		"agent.name",
+		"index",
+	}
+
+	// defaultStripPrefixes lists environment prefixes removed from service names
+	defaultStripPrefixes = []string{
+		"lc-jade-prod_",
+		"lc-jade-staging_",
+		"lc-jade-dev_",
+		"prod_",
+		"staging_",
+		"dev_",
+	}
+
+	// defaultServicePatterns extract service names from various formats (capture group 1)
+	defaultServicePatterns = []string{
+		// Container names like "lc-jade-prod_pp-slot-rpc-dd4bcd599-vlkp5"
+		`([a-zA-Z0-9-_]+)(?:-[a-f0-9]{8,10}-[a-z0-9]{5})?$`,
+		// Service names with environment prefixes
+		`^(?:lc-jade-prod_)?([a-zA-Z0-9-_]+)`,
+		// Kubernetes pod names
+		`^([a-zA-Z0-9-]+)-[a-f0-9]+-[a-z0-9]+$`,
+		// Generic service name pattern
+		`^([a-zA-Z0-9][a-zA-Z0-9-_]*[a-zA-Z0-9])$`,
+	}
+
+	// defaultServiceDenyList lists names that are never accepted as services
+	defaultServiceDenyList = []string{
+		"filebeat",
+		"logstash",
+		"fluentd",
+		"unknown",
+		"default",
+		"system",
+		"kernel",
+	}
+
+	// skippedPathParts are path components ignored when scanning file paths
+	skippedPathParts = map[string]bool{
+		"var": true, "lib": true, "log": true, "docker": true, "containers": true, "pods": true,
+	}
+
+	// podLogPathRegex matches kubelet pod log paths: /var/log/pods/<namespace>_<pod>_<uid>/<container>/0.log
+	podLogPathRegex = regexp.MustCompile(`/var/log/pods/([^_/]+)_([^_/]+)_[^/]+/([^/]+)/`)
+
+	// containerLogPathRegex matches symlinked container logs: /var/log/containers/<pod>_<namespace>_<container>-<id>.log
+	containerLogPathRegex = regexp.MustCompile(`/var/log/containers/([^_/]+)_([^_/]+)_(.+)-[0-9a-f]{64}\.log$`)
+)
+
 // ServiceExtractor handles extraction of service names from various sources
 type ServiceExtractor struct {
+	sources         []string
+	stripPrefixes   []string
 	servicePatterns []*regexp.Regexp
+	denyList        map[string]bool
+}
+
+// KubernetesMetadata contains the workload location of a log line
+type KubernetesMetadata struct {
+	Namespace string
+	Pod       string
+	Container string
+	Node      string
 }
 
-// NewServiceExtractor creates a new service extractor
+// NewServiceExtractor creates a service extractor with the default rules
 func NewServiceExtractor() *ServiceExtractor {
-	// Patterns to extract service names from various formats
-	patterns := []*regexp.Regexp{
-		// Extract from container names like "lc-jade-prod_pp-slot-rpc-dd4bcd599-vlkp5"
-		regexp.MustCompile(`([a-zA-Z0-9-_]+)(?:-[a-f0-9]{8,10}-[a-z0-9]{5})?$`),
+	return NewServiceExtractorWithConfig(config.ServiceExtractionConfig{})
+}
 
-		// Extract from service names with environment prefixes
-		regexp.MustCompile(`^(?:lc-jade-prod_)?([a-zA-Z0-9-_]+)`),
+// NewServiceExtractorWithConfig creates a service extractor from configured rules.
+// Empty rule lists fall back to the defaults; invalid patterns are skipped
+// (they are rejected earlier by config validation).
+func NewServiceExtractorWithConfig(cfg config.ServiceExtractionConfig) *ServiceExtractor {
+	sources := cfg.Sources
+	if len(sources) == 0 {
+		sources = defaultServiceSources
+	}
 
-		// Extract from Kubernetes pod names
-		regexp.MustCompile(`^([a-zA-Z0-9-]+)-[a-f0-9]+-[a-z0-9]+$`),
+	prefixes := cfg.StripPrefixes
+	if len(prefixes) == 0 {
+		prefixes = defaultStripPrefixes
+	}
 
-		// Generic service name pattern
-		regexp.MustCompile(`^([a-zA-Z0-9][a-zA-Z0-9-_]*[a-zA-Z0-9])$`),
+	patternStrs := cfg.Patterns
+	if len(patternStrs) == 0 {
+		patternStrs = defaultServicePatterns
+	}
+	patterns := make([]*regexp.Regexp, 0, len(patternStrs))
+	for _, p := range patternStrs {
+		if compiled, err := regexp.Compile(p); err == nil {
+			patterns = append(patterns, compiled)
+		}
+	}
+
+	denied := cfg.DenyList
+	if len(denied) == 0 {
+		denied = defaultServiceDenyList
+	}
+	denyList := make(map[string]bool, len(denied))
+	for _, name := range denied {
+		denyList[strings.ToLower(name)] = true
 	}
 
 	return &ServiceExtractor{
+		sources:         sources,
+		stripPrefixes:   prefixes,
 		servicePatterns: patterns,
+		denyList:        denyList,
 	}
 }
 
-// ExtractServiceName extracts service name from raw log
+// ExtractServiceName extracts service name from raw log, trying each configured source in order
 func (se *ServiceExtractor) ExtractServiceName(rawLog models.RawLog) (string, error) {
-	// Primary source: fields.servicename
-	if rawLog.Source.Fields.ServiceName != "" {
-		return se.normalizeServiceName(rawLog.Source.Fields.ServiceName), nil
+	for _, source := range se.sources {
+		value := sourceValue(rawLog, source)
+		if value == "" {
+			continue
+		}
+
+		var serviceName string
+		switch source {
+		case "index":
+			serviceName = se.normalizeServiceName(extractServiceFromIndex(value))
+		case "log.file.path":
+			serviceName = se.extractFromFilePath(value)
+		default:
+			serviceName = se.extractFromString(se.stripPrefix(value))
+		}
+
+		if se.isValidServiceName(serviceName) {
+			return serviceName, nil
+		}
 	}
 
-	// Secondary sources: try to extract from other fields
-	sources := []string{
-		rawLog.Source.Host["name"].(string), // This might cause panic, need to handle safely
+	return "", fmt.Errorf("unable to extract service name from raw log")
+}
+
+// NormalizeServiceName applies the extraction rules (prefix stripping, patterns, cleanup) to a
+// bare field value, so names from other sources match the ServiceName of parsed logs.
+// It returns "" when the value yields no valid service name.
+func (se *ServiceExtractor) NormalizeServiceName(value string) string {
+	return se.extractFromString(se.stripPrefix(value))
+}
+
+// ExtractKubernetesMetadata extracts namespace, pod, container and node of a log line.
+// Filebeat/fluentd kubernetes metadata is preferred; the log file path is used as fallback.
+func (se *ServiceExtractor) ExtractKubernetesMetadata(rawLog models.RawLog) KubernetesMetadata {
+	k8s := rawLog.Source.Kubernetes
+
+	md := KubernetesMetadata{
+		Namespace: firstNonEmpty(stringAt(k8s, "namespace"), stringAt(k8s, "namespace_name")),
+		Pod:       firstNonEmpty(stringAt(k8s, "pod", "name"), stringAt(k8s, "pod_name")),
+		Container: firstNonEmpty(stringAt(k8s, "container", "name"), stringAt(k8s, "container_name")),
+		Node:      firstNonEmpty(stringAt(k8s, "node", "name"), stringAt(k8s, "host")),
 	}
 
-	// Safely extract host name
-	if hostMap, ok := rawLog.Source.Host["name"]; ok {
-		if hostName, ok := hostMap.(string); ok {
-			sources = append(sources, hostName)
+	if md.Pod == "" {
+		path := stringAt(rawLog.Source.Log, "file", "path")
+		if m := podLogPathRegex.FindStringSubmatch(path); m != nil {
+			md.Namespace = firstNonEmpty(md.Namespace, m[1])
+			md.Pod = m[2]
+			md.Container = firstNonEmpty(md.Container, m[3])
+		} else if m := containerLogPathRegex.FindStringSubmatch(path); m != nil {
+			md.Pod = m[1]
+			md.Namespace = firstNonEmpty(md.Namespace, m[2])
+			md.Container = firstNonEmpty(md.Container, m[3])
 		}
 	}
 
-	// Try agent name
-	if agentMap, ok := rawLog.Source.Agent["name"]; ok {
-		if agentName, ok := agentMap.(string); ok {
-			sources = append(sources, agentName)
-		}
+	if md.Node == "" {
+		md.Node = stringAt(rawLog.Source.Host, "name")
 	}
 
-	// Try to extract from log file path
-	if logMap, ok := rawLog.Source.Log["file"]; ok {
-		if fileMap, ok := logMap.(map[string]interface{}); ok {
-			if filePath, ok := fileMap["path"].(string); ok {
-				if serviceName := se.extractFromFilePath(filePath); serviceName != "" {
-					return se.normalizeServiceName(serviceName), nil
-				}
-			}
+	return md
+}
+
+// sourceValue resolves a dotted source path (e.g. "kubernetes.pod.name") against a raw log
+func sourceValue(rawLog models.RawLog, path string) string {
+	parts := strings.Split(path, ".")
+
+	switch parts[0] {
+	case "index":
+		return rawLog.Index
+	case "fields":
+		if len(parts) == 2 && parts[1] == "servicename" {
+			return rawLog.Source.Fields.ServiceName
 		}
+		return ""
+	case "host":
+		return stringAt(rawLog.Source.Host, parts[1:]...)
+	case "agent":
+		return stringAt(rawLog.Source.Agent, parts[1:]...)
+	case "log":
+		return stringAt(rawLog.Source.Log, parts[1:]...)
+	case "kubernetes":
+		return stringAt(rawLog.Source.Kubernetes, parts[1:]...)
 	}
 
-	// Try each source
-	for _, source := range sources {
-		if source != "" {
-			if serviceName := se.extractFromString(source); serviceName != "" {
-				return se.normalizeServiceName(serviceName), nil
-			}
+	return ""
+}
+
+// stringAt walks nested maps along keys and returns the string found, or ""
+func stringAt(m map[string]interface{}, keys ...string) string {
+	if len(keys) == 0 {
+		return ""
+	}
+
+	var current interface{} = m
+	for _, key := range keys {
+		nested, ok := current.(map[string]interface{})
+		if !ok {
+			return ""
 		}
+		current = nested[key]
 	}
 
-	return "", fmt.Errorf("unable to extract service name from raw log")
+	s, _ := current.(string)
+	return s
+}
+
+// firstNonEmpty returns the first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
 }
 
 // extractFromString attempts to extract service name from a string using patterns
@@ -94,7 +259,7 @@ func (se *ServiceExtractor) extractFromString(input string) string {
 	for _, pattern := range se.servicePatterns {
 		matches := pattern.FindStringSubmatch(input)
 		if len(matches) >= 2 {
-			serviceName := matches[1]
+			serviceName := se.cleanServiceName(matches[1])
 			if se.isValidServiceName(serviceName) {
 				return serviceName
 			}
@@ -118,17 +283,12 @@ func (se *ServiceExtractor) extractFromFilePath(filePath string) string {
 	pathParts := strings.Split(filePath, "/")
 
 	for _, part := range pathParts {
-		if part == "" {
-			continue
-		}
-
-		// Skip common path components
-		if part == "var" || part == "lib" || part == "docker" || part == "containers" {
+		if part == "" || skippedPathParts[part] {
 			continue
 		}
 
 		// Try to extract service name from this part
-		if serviceName := se.extractFromString(part); serviceName != "" {
+		if serviceName := se.extractFromString(se.stripPrefix(part)); serviceName != "" {
 			return serviceName
 		}
 	}
@@ -136,30 +296,19 @@ func (se *ServiceExtractor) extractFromFilePath(filePath string) string {
 	return ""
 }
 
-// normalizeServiceName normalizes the service name
-func (se *ServiceExtractor) normalizeServiceName(serviceName string) string {
-	// Remove common prefixes
-	prefixes := []string{
-		"lc-jade-prod_",
-		"lc-jade-staging_",
-		"lc-jade-dev_",
-		"prod_",
-		"staging_",
-		"dev_",
-	}
-
-	normalized := serviceName
-	for _, prefix := range prefixes {
-		if strings.HasPrefix(normalized, prefix) {
-			normalized = strings.TrimPrefix(normalized, prefix)
-			break
+// stripPrefix removes the first matching environment prefix
+func (se *ServiceExtractor) stripPrefix(serviceName string) string {
+	for _, prefix := range se.stripPrefixes {
+		if strings.HasPrefix(serviceName, prefix) {
+			return strings.TrimPrefix(serviceName, prefix)
 		}
 	}
+	return serviceName
+}
 
-	// Clean up the name
-	normalized = se.cleanServiceName(normalized)
-
-	return normalized
+// normalizeServiceName normalizes the service name
+func (se *ServiceExtractor) normalizeServiceName(serviceName string) string {
+	return se.cleanServiceName(se.stripPrefix(serviceName))
 }
 
 // cleanServiceName cleans up the service name
@@ -209,24 +358,8 @@ func (se *ServiceExtractor) isValidServiceName(serviceName string) bool {
 		return false
 	}
 
-	// Check for invalid patterns
-	invalidPatterns := []string{
-		"filebeat",
-		"logstash",
-		"fluentd",
-		"unknown",
-		"default",
-		"system",
-		"kernel",
-	}
-
-	for _, invalid := range invalidPatterns {
-		if serviceName == invalid {
-			return false
-		}
-	}
-
-	return true
+	// Check the deny-list
+	return !se.denyList[serviceName]
 }
 
 // GetServiceNameVariations returns possible variations of a service name
@@ -241,14 +374,8 @@ func (se *ServiceExtractor) GetServiceNameVariations(serviceName string) []strin
 		variations = append(variations, strings.ReplaceAll(serviceName, "_", "-"))
 	}
 
-	// Add variations with common prefixes
-	prefixes := []string{
-		"lc-jade-prod_",
-		"lc-jade-staging_",
-		"prod_",
-	}
-
-	for _, prefix := range prefixes {
+	// Add variations with configured prefixes
+	for _, prefix := range se.stripPrefixes {
 		variations = append(variations, prefix+serviceName)
 	}
 
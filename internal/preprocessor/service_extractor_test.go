@@ -0,0 +1,125 @@
+package preprocessor
+
+import (
+	"testing"
+
+	"log-analyzer/internal/config"
+	"log-analyzer/pkg/models"
+)
+
+func TestExtractServiceNameDefaults(t *testing.T) {
+	extractor := NewServiceExtractor()
+
+	tests := []struct {
+		name     string
+		rawLog   models.RawLog
+		expected string
+		hasError bool
+	}{
+		{
+			name:     "fields.servicename with environment prefix",
+			rawLog:   models.RawLog{Source: models.OpenSearchSource{Fields: models.FieldsData{ServiceName: "lc-jade-prod_pp-slot-rpc"}}},
+			expected: "pp-slot-rpc",
+		},
+		{
+			name: "Missing host.name does not panic",
+			rawLog: models.RawLog{Source: models.OpenSearchSource{
+				Agent: map[string]interface{}{"name": "pp-slot-math"},
+			}},
+			expected: "pp-slot-math",
+		},
+		{
+			name: "Deny-listed agent falls through to index",
+			rawLog: models.RawLog{Index: "pp-slot-api-log*", Source: models.OpenSearchSource{
+				Agent: map[string]interface{}{"name": "filebeat"},
+			}},
+			expected: "pp-slot-api",
+		},
+		{
+			name:     "Nothing to extract",
+			rawLog:   models.RawLog{},
+			hasError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := extractor.ExtractServiceName(tt.rawLog)
+			if tt.hasError {
+				if err == nil {
+					t.Errorf("Expected error, got %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestExtractServiceNameFromConfig(t *testing.T) {
+	extractor := NewServiceExtractorWithConfig(config.ServiceExtractionConfig{
+		Sources:       []string{"kubernetes.labels.app", "fields.servicename"},
+		StripPrefixes: []string{"team-a_"},
+		Patterns:      []string{`^([a-z-]+?)-v\d+$`},
+		DenyList:      []string{"sidecar"},
+	})
+
+	rawLog := models.RawLog{Source: models.OpenSearchSource{
+		Kubernetes: map[string]interface{}{"labels": map[string]interface{}{"app": "team-a_wallet-gateway-v2"}},
+		Fields:     models.FieldsData{ServiceName: "ignored"},
+	}}
+
+	result, err := extractor.ExtractServiceName(rawLog)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result != "wallet-gateway" {
+		t.Errorf("Expected 'wallet-gateway', got %q", result)
+	}
+}
+
+func TestExtractKubernetesMetadata(t *testing.T) {
+	extractor := NewServiceExtractor()
+
+	fromMetadata := extractor.ExtractKubernetesMetadata(models.RawLog{Source: models.OpenSearchSource{
+		Kubernetes: map[string]interface{}{
+			"namespace": "lc-jade-prod",
+			"pod":       map[string]interface{}{"name": "pp-slot-rpc-dd4bcd599-vlkp5"},
+			"container": map[string]interface{}{"name": "pp-slot-rpc"},
+			"node":      map[string]interface{}{"name": "node-7"},
+		},
+	}})
+	expected := KubernetesMetadata{Namespace: "lc-jade-prod", Pod: "pp-slot-rpc-dd4bcd599-vlkp5", Container: "pp-slot-rpc", Node: "node-7"}
+	if fromMetadata != expected {
+		t.Errorf("Expected %+v, got %+v", expected, fromMetadata)
+	}
+
+	fromPath := extractor.ExtractKubernetesMetadata(models.RawLog{Source: models.OpenSearchSource{
+		Log:  map[string]interface{}{"file": map[string]interface{}{"path": "/var/log/pods/lc-jade-prod_pp-slot-api-6c9f7b8d4-x2k9p_0b1c/pp-slot-api/0.log"}},
+		Host: map[string]interface{}{"name": "node-3"},
+	}})
+	expected = KubernetesMetadata{Namespace: "lc-jade-prod", Pod: "pp-slot-api-6c9f7b8d4-x2k9p", Container: "pp-slot-api", Node: "node-3"}
+	if fromPath != expected {
+		t.Errorf("Expected %+v, got %+v", expected, fromPath)
+	}
+}
+
+func TestNormalizeServiceName(t *testing.T) {
+	extractor := NewServiceExtractor()
+
+	tests := map[string]string{
+		"lc-jade-prod_PP_Slot_API": "pp-slot-api",
+		"pp-slot-rpc":              "pp-slot-rpc",
+		"filebeat":                 "",
+	}
+	for value, expected := range tests {
+		if got := extractor.NormalizeServiceName(value); got != expected {
+			t.Errorf("NormalizeServiceName(%q) = %q, expected %q", value, got, expected)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package preprocessor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"log-analyzer/pkg/models"
+)
+
+// ProcessStream parses raw logs received from in on the worker pool and sends the parsed
+// logs to out, closing out once in is closed and drained. Unlike Process, output order is
+// not preserved and neither the input nor the rejects are kept: each rejected log is handed
+// to onReject (one call at a time) and only counted per reason. It blocks until done and
+// returns the processing statistics.
+func (p *LogPreprocessor) ProcessStream(in <-chan models.RawLog, out chan<- models.ParsedLog, onReject func(RejectedLog)) ProcessingStats {
+	startTime := time.Now()
+
+	var (
+		mu    sync.Mutex
+		next  int
+		stats = ProcessingStats{LevelCounts: make(map[string]int), RejectReasons: make(map[RejectReason]int)}
+		wg    sync.WaitGroup
+	)
+
+	for w := 0; w < p.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rawLog := range in {
+				mu.Lock()
+				position := next
+				next++
+				mu.Unlock()
+
+				parsedLog, err := p.processRawLog(rawLog)
+				if err != nil {
+					reason := rejectReasonOf(err)
+					mu.Lock()
+					stats.RejectReasons[reason]++
+					if onReject != nil {
+						onReject(RejectedLog{
+							Position: position,
+							Reason:   reason,
+							Error:    fmt.Sprintf("failed to process log %d: %v", position, err),
+							RawLog:   rawLog,
+						})
+					}
+					mu.Unlock()
+					continue
+				}
+				if parsedLog == nil {
+					continue
+				}
+
+				mu.Lock()
+				stats.SuccessfullyParsed++
+				stats.LevelCounts[string(parsedLog.Level)]++
+				mu.Unlock()
+				out <- *parsedLog
+			}
+		}()
+	}
+	wg.Wait()
+	close(out)
+
+	p.rejected = nil
+	p.throughput = Throughput{
+		Workers:  p.workers,
+		Duration: time.Since(startTime),
+	}
+
+	stats.TotalRawLogs = next
+	stats.Failed = next - stats.SuccessfullyParsed
+	if next > 0 {
+		stats.SuccessRate = float64(stats.SuccessfullyParsed) / float64(next)
+	}
+	stats.Workers = p.throughput.Workers
+	stats.DurationMs = p.throughput.Duration.Milliseconds()
+	if seconds := p.throughput.Duration.Seconds(); seconds > 0 {
+		stats.LogsPerSecond = float64(next) / seconds
+	}
+
+	return stats
+}
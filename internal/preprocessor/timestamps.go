@@ -0,0 +1,245 @@
+package preprocessor
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"log-analyzer/internal/config"
+	"log-analyzer/internal/interfaces"
+	"log-analyzer/pkg/models"
+)
+
+// Timestamp sources, usable in preprocessing.timestamps.order
+const (
+	TimestampInner   = "inner"   // @timestamp inside the application JSON
+	TimestampWrapper = "wrapper" // CRI/Kubernetes wrapper prefix written by the container runtime
+	TimestampSource  = "source"  // @timestamp of the OpenSearch document (shipper time)
+)
+
+// defaultTimestampOrder is used when no resolution order is configured
+var defaultTimestampOrder = []string{TimestampInner, TimestampWrapper, TimestampSource}
+
+// defaultSkewThreshold is the clock skew above which a pod is flagged
+const defaultSkewThreshold = 2 * time.Minute
+
+// naiveTimestampLayouts are accepted for timestamps without a UTC offset
+var naiveTimestampLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+}
+
+// TimestampResolver picks the timestamp of a log from the available sources
+type TimestampResolver struct {
+	order     []string
+	sourceLoc *time.Location
+}
+
+// NewTimestampResolver creates a resolver from configuration
+func NewTimestampResolver(cfg config.TimestampConfig) *TimestampResolver {
+	order := cfg.Order
+	if len(order) == 0 {
+		order = defaultTimestampOrder
+	}
+
+	loc := time.UTC
+	if cfg.SourceTimezone != "" {
+		if l, err := time.LoadLocation(cfg.SourceTimezone); err == nil {
+			loc = l
+		}
+	}
+
+	return &TimestampResolver{order: order, sourceLoc: loc}
+}
+
+// Resolve returns the first available timestamp in the configured order (normalized to UTC)
+// together with the name of the source it came from
+func (r *TimestampResolver) Resolve(candidates map[string]time.Time) (time.Time, string) {
+	for _, source := range r.order {
+		if ts := candidates[source]; !ts.IsZero() {
+			return ts.UTC(), source
+		}
+	}
+	return time.Time{}, ""
+}
+
+// Parse parses a raw timestamp: RFC3339 (with offset), naive date-times in the
+// source timezone, or epoch seconds/milliseconds/microseconds/nanoseconds
+func (r *TimestampResolver) Parse(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+
+	if ts, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		return ts, nil
+	}
+
+	for _, layout := range naiveTimestampLayouts {
+		if ts, err := time.ParseInLocation(layout, raw, r.sourceLoc); err == nil {
+			return ts, nil
+		}
+	}
+
+	if n, err := strconv.ParseFloat(raw, 64); err == nil {
+		return parseEpoch(raw, n), nil
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized timestamp format: %s", raw)
+}
+
+// parseEpoch converts an epoch number, picking the unit by magnitude:
+// above 1e17 nanoseconds, above 1e14 microseconds, above 1e11 milliseconds, else seconds
+func parseEpoch(raw string, n float64) time.Time {
+	abs := math.Abs(n)
+	if abs <= 1e11 {
+		sec := int64(n)
+		return time.Unix(sec, int64((n-float64(sec))*1e9))
+	}
+
+	// Integers are converted exactly; float64 cannot hold nanosecond precision
+	i, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		i = int64(n)
+	}
+	switch {
+	case abs > 1e17:
+		return time.Unix(0, i)
+	case abs > 1e14:
+		return time.UnixMicro(i)
+	default:
+		return time.UnixMilli(i)
+	}
+}
+
+// sourceTime is a timestamp field that may be encoded as a JSON string or number.
+// It is kept raw and parsed later with the configured source timezone.
+type sourceTime string
+
+// UnmarshalJSON accepts both "2026-01-10T19:30:32+08:00" and 1736508632 style values
+func (t *sourceTime) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = sourceTime(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*t = sourceTime(n.String())
+	return nil
+}
+
+// maxSkewSamples bounds the skews kept per pod; beyond it a reservoir sample is kept
+const maxSkewSamples = 1024
+
+// ClockSkewTracker accumulates per-pod clock skew one log at a time, in bounded memory
+type ClockSkewTracker struct {
+	byPod map[string]*podSkews
+	rng   *rand.Rand
+}
+
+// podSkews holds the skews seen for one pod: exact extremes and a sample for the median
+type podSkews struct {
+	node     string
+	seen     int
+	min, max time.Duration
+	skews    []time.Duration
+}
+
+// NewClockSkewTracker creates an empty tracker
+func NewClockSkewTracker() *ClockSkewTracker {
+	return &ClockSkewTracker{
+		byPod: make(map[string]*podSkews),
+		rng:   rand.New(rand.NewSource(1)),
+	}
+}
+
+// Add records the clock skew of a log, if it has one.
+// Logs without Kubernetes metadata are grouped by node, then by service.
+func (t *ClockSkewTracker) Add(log models.ParsedLog) {
+	if log.ClockSkew == nil {
+		return
+	}
+	key := firstNonEmpty(log.Pod, log.Node, log.ServiceName)
+	samples := t.byPod[key]
+	if samples == nil {
+		samples = &podSkews{node: log.Node}
+		t.byPod[key] = samples
+	}
+
+	skew := *log.ClockSkew
+	if samples.seen == 0 || skew < samples.min {
+		samples.min = skew
+	}
+	if samples.seen == 0 || skew > samples.max {
+		samples.max = skew
+	}
+	samples.seen++
+	if len(samples.skews) < maxSkewSamples {
+		samples.skews = append(samples.skews, *log.ClockSkew)
+	} else if j := t.rng.Intn(samples.seen); j < maxSkewSamples {
+		samples.skews[j] = *log.ClockSkew
+	}
+}
+
+// DetectClockSkew computes per-pod clock skew from parsed logs.
+// A pod is flagged when its median skew exceeds threshold (default 2m).
+// Logs without Kubernetes metadata are grouped by node, then by service.
+func DetectClockSkew(logs []models.ParsedLog, threshold time.Duration) []interfaces.PodClockSkew {
+	tracker := NewClockSkewTracker()
+	for _, log := range logs {
+		tracker.Add(log)
+	}
+	return tracker.Result(threshold)
+}
+
+// Result summarizes the skew per pod; a pod is flagged when its median skew exceeds threshold (default 2m)
+func (t *ClockSkewTracker) Result(threshold time.Duration) []interfaces.PodClockSkew {
+	if threshold <= 0 {
+		threshold = defaultSkewThreshold
+	}
+
+	var result []interfaces.PodClockSkew
+	for pod, samples := range t.byPod {
+		sort.Slice(samples.skews, func(i, j int) bool { return samples.skews[i] < samples.skews[j] })
+
+		// The median comes from the sample, the maximum from every observation
+		median := samples.skews[len(samples.skews)/2]
+		maxSkew := samples.min
+		if absDuration(samples.max) > absDuration(maxSkew) {
+			maxSkew = samples.max
+		}
+
+		result = append(result, interfaces.PodClockSkew{
+			Pod:        pod,
+			Node:       samples.node,
+			Samples:    samples.seen,
+			MedianSkew: median,
+			MaxSkew:    maxSkew,
+			Flagged:    absDuration(median) > threshold,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return absDuration(result[i].MedianSkew) > absDuration(result[j].MedianSkew)
+	})
+
+	return result
+}
+
+// absDuration returns the absolute value of a duration
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,161 @@
+package preprocessor
+
+import (
+	"testing"
+	"time"
+
+	"log-analyzer/internal/config"
+	"log-analyzer/pkg/models"
+)
+
+func TestProcessRawLogTimestampResolution(t *testing.T) {
+	fields := models.FieldsData{ServiceName: "test-service"}
+	sourceTime := time.Date(2026, 1, 10, 11, 30, 40, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		cfg            config.TimestampConfig
+		message        string
+		expected       time.Time
+		expectedSource string
+		expectedSkew   *time.Duration
+	}{
+		{
+			name:           "Inner timestamp normalized to UTC",
+			message:        `2026-01-10T11:30:32.804Z stderr F {"@timestamp":"2026-01-10T19:30:32.804+08:00","content":"x","level":"error"}`,
+			expected:       time.Date(2026, 1, 10, 11, 30, 32, 804000000, time.UTC),
+			expectedSource: TimestampInner,
+			expectedSkew:   new(time.Duration),
+		},
+		{
+			name:           "Missing inner timestamp falls back to wrapper",
+			message:        `2026-01-10T11:30:32.5Z stdout F {"content":"x","level":"error"}`,
+			expected:       time.Date(2026, 1, 10, 11, 30, 32, 500000000, time.UTC),
+			expectedSource: TimestampWrapper,
+		},
+		{
+			name:           "No wrapper falls back to document timestamp",
+			message:        `{"content":"x","level":"error"}`,
+			expected:       sourceTime,
+			expectedSource: TimestampSource,
+		},
+		{
+			name:           "Naive inner timestamp uses source timezone",
+			cfg:            config.TimestampConfig{SourceTimezone: "Asia/Taipei"},
+			message:        `{"@timestamp":"2026-01-10 19:30:00","content":"x","level":"error"}`,
+			expected:       time.Date(2026, 1, 10, 11, 30, 0, 0, time.UTC),
+			expectedSource: TimestampInner,
+		},
+		{
+			name:           "Unparseable inner timestamp falls back to wrapper",
+			message:        `2026-01-10T11:30:32.5Z stdout F {"@timestamp":"yesterday","content":"x","level":"error"}`,
+			expected:       time.Date(2026, 1, 10, 11, 30, 32, 500000000, time.UTC),
+			expectedSource: TimestampWrapper,
+		},
+		{
+			name:           "Configured order prefers the document timestamp",
+			cfg:            config.TimestampConfig{Order: []string{"source", "inner"}},
+			message:        `{"@timestamp":"2026-01-10T19:30:32+08:00","content":"x","level":"error"}`,
+			expected:       sourceTime,
+			expectedSource: TimestampSource,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := NewLogPreprocessorWithConfig(config.PreprocessingConfig{Timestamps: tt.cfg})
+			rawLog := models.RawLog{Source: models.OpenSearchSource{
+				Message:   tt.message,
+				Fields:    fields,
+				Timestamp: sourceTime,
+			}}
+
+			result, err := processor.processRawLog(rawLog)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !result.Timestamp.Equal(tt.expected) || result.Timestamp.Location() != time.UTC {
+				t.Errorf("Expected %v (UTC), got %v", tt.expected, result.Timestamp)
+			}
+			if result.TimestampSource != tt.expectedSource {
+				t.Errorf("Expected source %q, got %q", tt.expectedSource, result.TimestampSource)
+			}
+			if tt.expectedSkew != nil && (result.ClockSkew == nil || *result.ClockSkew != *tt.expectedSkew) {
+				t.Errorf("Expected skew %v, got %v", *tt.expectedSkew, result.ClockSkew)
+			}
+		})
+	}
+}
+
+func TestProcessRawLogRejectsUnresolvableTimestamp(t *testing.T) {
+	processor := NewLogPreprocessor()
+	rawLog := models.RawLog{Source: models.OpenSearchSource{
+		Message: `{"@timestamp":"yesterday","content":"x","level":"error"}`,
+		Fields:  models.FieldsData{ServiceName: "test-service"},
+	}}
+
+	_, err := processor.processRawLog(rawLog)
+	if reason := rejectReasonOf(err); err == nil || reason != RejectBadTimestamp {
+		t.Errorf("Expected %s rejection, got %v (%s)", RejectBadTimestamp, err, reason)
+	}
+}
+
+func TestTimestampResolverParseEpochUnits(t *testing.T) {
+	resolver := NewTimestampResolver(config.TimestampConfig{})
+	expected := time.Date(2026, 1, 10, 11, 30, 32, 0, time.UTC)
+
+	for _, raw := range []string{"1768044632", "1768044632000", "1768044632000000", "1768044632000000000"} {
+		ts, err := resolver.Parse(raw)
+		if err != nil {
+			t.Fatalf("Parse(%s) returned error: %v", raw, err)
+		}
+		if !ts.Equal(expected) {
+			t.Errorf("Parse(%s) = %v, expected %v", raw, ts.UTC(), expected)
+		}
+	}
+}
+
+func TestDetectClockSkew(t *testing.T) {
+	skew := func(d time.Duration) *time.Duration { return &d }
+
+	logs := []models.ParsedLog{
+		{Pod: "api-1", Node: "node-1", ClockSkew: skew(time.Second)},
+		{Pod: "api-1", Node: "node-1", ClockSkew: skew(-2 * time.Second)},
+		{Pod: "rpc-1", Node: "node-2", ClockSkew: skew(10 * time.Minute)},
+		{Pod: "rpc-1", Node: "node-2", ClockSkew: skew(11 * time.Minute)},
+		{Pod: "rpc-1", Node: "node-2", ClockSkew: skew(9 * time.Minute)},
+		{Pod: "math-1"},
+	}
+
+	result := DetectClockSkew(logs, 0)
+	if len(result) != 2 {
+		t.Fatalf("Expected 2 pods, got %d", len(result))
+	}
+	if result[0].Pod != "rpc-1" || !result[0].Flagged || result[0].MedianSkew != 10*time.Minute || result[0].MaxSkew != 11*time.Minute {
+		t.Errorf("Unexpected skew for rpc-1: %+v", result[0])
+	}
+	if result[1].Flagged {
+		t.Errorf("api-1 should not be flagged: %+v", result[1])
+	}
+}
+
+func TestClockSkewTrackerKeepsExactMax(t *testing.T) {
+	tracker := NewClockSkewTracker()
+	add := func(d time.Duration) {
+		tracker.Add(models.ParsedLog{Pod: "api-1", ClockSkew: &d})
+	}
+
+	// One early outlier, then far more logs than the sample holds
+	add(-30 * time.Minute)
+	for i := 0; i < 50*maxSkewSamples; i++ {
+		add(time.Second)
+	}
+
+	result := tracker.Result(0)
+	if len(result) != 1 || result[0].MaxSkew != -30*time.Minute || result[0].MedianSkew != time.Second {
+		t.Errorf("expected max skew -30m and median 1s, got %+v", result)
+	}
+	if result[0].Samples != 50*maxSkewSamples+1 {
+		t.Errorf("expected %d samples, got %d", 50*maxSkewSamples+1, result[0].Samples)
+	}
+}
@@ -0,0 +1,288 @@
+package redactor
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"log-analyzer/internal/config"
+	"log-analyzer/pkg/models"
+)
+
+// Policy defines how a detected sensitive value is handled
+type Policy string
+
+const (
+	// PolicyMask replaces the value with a typed placeholder, e.g. [EMAIL]
+	PolicyMask Policy = "mask"
+	// PolicyHash replaces the value with a stable salted hash, e.g. [EMAIL:9f86d081]
+	PolicyHash Policy = "hash"
+	// PolicyDrop replaces the whole field with [REDACTED] once anything is detected
+	PolicyDrop Policy = "drop"
+)
+
+// detector finds one kind of sensitive value.
+// When the pattern has a capture group, only the first group is redacted
+// (e.g. the value of "mgckey=..." but not the key name).
+type detector struct {
+	name     string
+	label    string
+	pattern  *regexp.Regexp
+	policy   Policy // empty: use the field policy
+	validate func(string) bool
+}
+
+// builtinDetectors returns the built-in detectors in application order
+func builtinDetectors() []detector {
+	return []detector{
+		{
+			name:    "mgckey",
+			label:   "MGCKEY",
+			pattern: regexp.MustCompile(`(?i)mgckey\\?"?\s*[=:]\s*\\?"?([^\s&"',;\\]+)`),
+		},
+		{
+			name:    "bearer",
+			label:   "TOKEN",
+			pattern: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/=-]{8,})`),
+		},
+		{
+			name:    "jwt",
+			label:   "JWT",
+			pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+		},
+		{
+			name:    "email",
+			label:   "EMAIL",
+			pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+		},
+		{
+			// Only separator-formatted numbers: about 10% of bare numeric IDs pass the Luhn
+			// check, and redacting only those would split one error into several groups
+			name:  "card",
+			label: "CARD",
+			pattern: regexp.MustCompile(
+				`\b\d{4}(?: \d{4}){3}(?: \d{1,3})?\b|\b\d{4}(?:-\d{4}){3}(?:-\d{1,3})?\b|\b\d{4}[ -]\d{6}[ -]\d{5}\b`),
+			validate: luhnValid,
+		},
+		{
+			name:  "phone",
+			label: "PHONE",
+			pattern: regexp.MustCompile(
+				`\+\d{1,3}[ -]?\d{2,4}[ -]?\d{3,4}[ -]?\d{3,4}\b|\b09\d{2}-?\d{3}-?\d{3}\b|\(\d{2,4}\)\s?\d{3,4}-\d{3,4}\b`),
+		},
+	}
+}
+
+// Redactor removes PII and secrets from parsed logs
+type Redactor struct {
+	enabled   bool
+	detectors []detector
+	fields    map[string]Policy // field -> policy; content is always scanned
+	salt      string
+}
+
+// Stats counts redacted values per detector
+type Stats struct {
+	LogsRedacted int            `json:"logs_redacted"`
+	Matches      map[string]int `json:"matches"` // detector -> count
+}
+
+// NewRedactor creates a redactor from configuration.
+// Unknown built-ins and invalid patterns are skipped (they are rejected by config validation).
+func NewRedactor(cfg config.RedactionConfig) *Redactor {
+	r := &Redactor{
+		enabled: !cfg.Disabled,
+		fields:  map[string]Policy{"content": PolicyMask},
+		salt:    cfg.HashSalt,
+	}
+
+	enabled := make(map[string]bool)
+	for _, name := range cfg.Builtins {
+		enabled[name] = true
+	}
+	for _, d := range builtinDetectors() {
+		if len(enabled) == 0 || enabled[d.name] {
+			r.detectors = append(r.detectors, d)
+		}
+	}
+
+	for _, rule := range cfg.Rules {
+		compiled, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			continue
+		}
+		r.detectors = append(r.detectors, detector{
+			name:    rule.Name,
+			label:   strings.ToUpper(rule.Name),
+			pattern: compiled,
+			policy:  Policy(rule.Policy),
+		})
+	}
+
+	for field, policy := range cfg.Fields {
+		r.fields[field] = Policy(policy)
+	}
+
+	return r
+}
+
+// Redact redacts the configured fields of every log in place and returns match statistics
+func (r *Redactor) Redact(logs []models.ParsedLog) Stats {
+	stats := Stats{Matches: make(map[string]int)}
+	if !r.enabled {
+		return stats
+	}
+
+	for i := range logs {
+		r.RedactLog(&logs[i], &stats)
+	}
+
+	return stats
+}
+
+// RedactLog redacts the configured fields of one log in place, adding to stats
+func (r *Redactor) RedactLog(log *models.ParsedLog, stats *Stats) {
+	if !r.enabled {
+		return
+	}
+	if stats.Matches == nil {
+		stats.Matches = make(map[string]int)
+	}
+
+	redacted := false
+	for field, policy := range r.fields {
+		value := fieldRef(log, field)
+		if value == nil || *value == "" {
+			continue
+		}
+		if r.redactValue(value, policy, stats.Matches) {
+			redacted = true
+		}
+	}
+	if redacted {
+		stats.LogsRedacted++
+	}
+}
+
+// RedactString redacts free text (e.g. raw messages) using the content policy
+func (r *Redactor) RedactString(s string) string {
+	if !r.enabled {
+		return s
+	}
+	r.redactValue(&s, r.fields["content"], make(map[string]int))
+	return s
+}
+
+// redactValue applies all detectors to *value and reports whether anything matched
+func (r *Redactor) redactValue(value *string, fieldPolicy Policy, matches map[string]int) bool {
+	found := false
+	text := *value
+
+	for _, d := range r.detectors {
+		policy := d.policy
+		if policy == "" {
+			policy = fieldPolicy
+		}
+
+		text = replaceMatches(d, text, func(secret string) string {
+			found = true
+			matches[d.name]++
+			if policy == PolicyHash {
+				return fmt.Sprintf("[%s:%s]", d.label, r.hash(secret))
+			}
+			return fmt.Sprintf("[%s]", d.label)
+		})
+	}
+
+	if found && fieldPolicy == PolicyDrop {
+		text = "[REDACTED]"
+	}
+
+	*value = text
+	return found
+}
+
+// replaceMatches replaces each detected value (or its first capture group) using replace
+func replaceMatches(d detector, text string, replace func(string) string) string {
+	locs := d.pattern.FindAllStringSubmatchIndex(text, -1)
+	if len(locs) == 0 {
+		return text
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, loc := range locs {
+		start, end := loc[0], loc[1]
+		if len(loc) >= 4 && loc[2] >= 0 {
+			start, end = loc[2], loc[3]
+		}
+
+		secret := text[start:end]
+		if d.validate != nil && !d.validate(secret) {
+			continue
+		}
+
+		sb.WriteString(text[last:start])
+		sb.WriteString(replace(secret))
+		last = end
+	}
+	sb.WriteString(text[last:])
+
+	return sb.String()
+}
+
+// hash returns a short stable salted hash of a secret
+func (r *Redactor) hash(secret string) string {
+	sum := sha256.Sum256([]byte(r.salt + secret))
+	return fmt.Sprintf("%x", sum[:4])
+}
+
+// fieldRef returns a pointer to the named field of a parsed log, or nil if unsupported
+func fieldRef(log *models.ParsedLog, field string) *string {
+	switch field {
+	case "content":
+		return &log.Content
+	case "caller":
+		return &log.Caller
+	case "trace":
+		return &log.Trace
+	case "span":
+		return &log.Span
+	case "pod":
+		return &log.Pod
+	case "node":
+		return &log.Node
+	case "namespace":
+		return &log.Namespace
+	case "container":
+		return &log.Container
+	}
+	return nil
+}
+
+// luhnValid checks a card-like digit sequence with the Luhn algorithm
+func luhnValid(s string) bool {
+	sum := 0
+	digits := 0
+	double := false
+
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+
+	return digits >= 13 && sum%10 == 0
+}
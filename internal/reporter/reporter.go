@@ -7,27 +7,52 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"log-analyzer/internal/aggregator"
 	"log-analyzer/internal/config"
 	"log-analyzer/internal/interfaces"
+	"log-analyzer/internal/trend"
 	"log-analyzer/pkg/models"
 )
 
 // MarkdownReporter implements the Reporter interface
 type MarkdownReporter struct {
 	reportPath string
+	location   *time.Location                // display timezone
+	groups     map[string]*models.ErrorGroup // error group ID -> group
 }
 
 // NewMarkdownReporter creates a new markdown reporter
 func NewMarkdownReporter(reportPath string) *MarkdownReporter {
 	return &MarkdownReporter{
 		reportPath: reportPath,
+		location:   time.Local,
 	}
 }
 
+// SetDisplayLocation sets the timezone used for all times shown in reports
+func (r *MarkdownReporter) SetDisplayLocation(loc *time.Location) {
+	if loc != nil {
+		r.location = loc
+	}
+}
+
+// SetErrorGroups provides the error groups behind the analyses, so reports can show group details
+func (r *MarkdownReporter) SetErrorGroups(groups []models.ErrorGroup) {
+	r.groups = make(map[string]*models.ErrorGroup, len(groups))
+	for i := range groups {
+		r.groups[models.ShortFingerprint(groups[i].Fingerprint)] = &groups[i]
+	}
+}
+
+// groupFor returns the error group of an analysis, or nil if unknown
+func (r *MarkdownReporter) groupFor(a models.Analysis) *models.ErrorGroup {
+	return r.groups[a.ErrorGroupID]
+}
+
 // Generate generates a markdown report from analysis results
 func (r *MarkdownReporter) Generate(analyses []models.Analysis, stats *interfaces.AggregationResult) (*models.Report, error) {
 	// Create report directory if not exists
@@ -115,7 +140,7 @@ func (r *MarkdownReporter) generateReportContent(analyses []models.Analysis, sta
 
 	// Header
 	sb.WriteString("# 🔍 每日錯誤分析報告\n\n")
-	sb.WriteString(fmt.Sprintf("**生成時間**: %s  \n", time.Now().Format("2006-01-02 15:04:05")))
+	sb.WriteString(fmt.Sprintf("**生成時間**: %s  \n", time.Now().In(r.location).Format("2006-01-02 15:04:05 MST")))
 
 	// Calculate and display query duration
 	duration := stats.TimeStats.QueryDuration
@@ -146,6 +171,21 @@ func (r *MarkdownReporter) generateReportContent(analyses []models.Analysis, sta
 	// Secondary Issues (low frequency, summary format)
 	r.writeSecondaryIssuesSection(&sb, sortedAnalyses, stats)
 
+	// Parse failures (logs quarantined to the dead-letter file)
+	r.writeParseFailuresSection(&sb, stats)
+
+	// Pods whose clocks disagree with the runtime/shipper clock
+	r.writeClockSkewSection(&sb, stats)
+
+	// Errors grouped by innermost cause across callers and services
+	r.writeRootCauseSection(&sb, stats)
+
+	// Cascade chains linked through shared trace IDs
+	r.writeCascadeSection(&sb, stats)
+
+	// Absolute timeline and hour-of-day / weekday profiles
+	r.writeTimeDistributionSection(&sb, stats)
+
 	return sb.String()
 }
 
@@ -156,11 +196,14 @@ func (r *MarkdownReporter) writeDailyVerdictSection(sb *strings.Builder, analyse
 	highCount := countHighPriority(analyses)
 	totalLogs := stats.TotalLogs
 
+	// Firing SLO burn-rate alerts weigh in with their severity, so the verdict follows error ratios
+	pageAlerts, ticketAlerts := countFiringBurnRates(stats)
+
 	var verdict string
-	if highCount >= 3 {
-		verdict = "🔴 **危急** - 檢測到多個高嚴重性問題。需要立即調查。"
-	} else if highCount > 0 {
-		verdict = "🟡 **警告** - 存在高嚴重性問題。優先修復這些項目。"
+	if highCount >= 3 || pageAlerts > 0 {
+		verdict = "🔴 **危急** - 檢測到多個高嚴重性問題或錯誤預算快速燃燒。需要立即調查。"
+	} else if highCount > 0 || ticketAlerts > 0 {
+		verdict = "🟡 **警告** - 存在高嚴重性問題或錯誤預算持續燃燒。優先修復這些項目。"
 	} else {
 		verdict = "🟢 **正常** - 沒有危急問題。監控持續進行的模式。"
 	}
@@ -168,25 +211,57 @@ func (r *MarkdownReporter) writeDailyVerdictSection(sb *strings.Builder, analyse
 	sb.WriteString(fmt.Sprintf("%s\n\n", verdict))
 	sb.WriteString(fmt.Sprintf("- **總錯誤數**: %d 個錯誤，涉及 %d 個唯一模式\n", totalLogs, stats.TotalErrorGroups))
 	sb.WriteString(fmt.Sprintf("- **高優先級問題**: %d 個\n", highCount))
+	if anomalous := countAnomalous(analyses); anomalous > 0 {
+		sb.WriteString(fmt.Sprintf("- **高於歷史基準**: %d 個錯誤模式\n", anomalous))
+	}
+	if stats.TrendAnalysis != nil {
+		sb.WriteString(fmt.Sprintf("- **趨勢**: %s\n", formatTrend(stats.TrendAnalysis)))
+	}
+
+	// Distinct affected entities per service (approximate)
+	services := make([]string, 0, len(stats.ServiceStats))
+	for serviceName := range stats.ServiceStats {
+		services = append(services, serviceName)
+	}
+	sort.Strings(services)
+	for _, serviceName := range services {
+		if counts := stats.ServiceStats[serviceName].DistinctCounts; len(counts) > 0 {
+			sb.WriteString(fmt.Sprintf("- **受影響實體**（%s）: %s\n", serviceName, formatTopCounts(counts, len(counts))))
+		}
+	}
 
-	// Display peak window with 30-minute granularity
-	var peakTimeStr string
+	// Error ratios and SLO burn rates per service (volume.enabled)
+	for _, serviceName := range services {
+		serviceStats := stats.ServiceStats[serviceName]
+		if serviceStats.ErrorRatio != nil {
+			sb.WriteString(fmt.Sprintf("- **錯誤比率**（%s）: %s\n", serviceName, r.formatErrorRatio(serviceStats.ErrorRatio)))
+		}
+		if len(serviceStats.BurnRates) > 0 {
+			sb.WriteString(fmt.Sprintf("- **SLO 燃燒率**（%s，目標 %s）: %s\n", serviceName,
+				formatPercent(serviceStats.BurnRates[0].Target), formatBurnRates(serviceStats.BurnRates)))
+		}
+	}
+
+	// Onset and recovery of rate shifts per service
+	for _, serviceName := range services {
+		if episodes := stats.ServiceStats[serviceName].Episodes; len(episodes) > 0 {
+			sb.WriteString(fmt.Sprintf("- **異常時段**（%s）: %s\n", serviceName, r.formatEpisodes(episodes)))
+		}
+	}
+
+	// Display the sliding peak window, with its actual date
 	if !stats.TimeStats.PeakWindowStart.IsZero() && !stats.TimeStats.PeakWindowEnd.IsZero() {
-		// Use the calculated peak window (30 minutes)
-		peakTimeStr = fmt.Sprintf("%s 至 %s",
-			stats.TimeStats.PeakWindowStart.Format("2006-01-02 15:04"),
-			stats.TimeStats.PeakWindowEnd.Format("15:04"))
-		sb.WriteString(fmt.Sprintf("- **峰值時段**: %s（%d 個錯誤）\n", peakTimeStr, stats.TimeStats.PeakWindowCount))
-	} else {
-		// Fallback: use hourly peak if window not available
-		peakStart := stats.TimeStats.EarliestLogTime
-		hour := time.Date(peakStart.Year(), peakStart.Month(), peakStart.Day(),
-			stats.TimeStats.PeakHour, 0, 0, 0, peakStart.Location())
-		peakEnd := hour.Add(time.Hour)
-		peakTimeStr = fmt.Sprintf("%s 至 %s",
-			hour.Format("2006-01-02 15:00"),
-			peakEnd.Format("15:00"))
-		sb.WriteString(fmt.Sprintf("- **峰值時段**: %s（%d 個錯誤）\n", peakTimeStr, stats.TimeStats.PeakCount))
+		peakStart := stats.TimeStats.PeakWindowStart.In(r.location)
+		peakEnd := stats.TimeStats.PeakWindowEnd.In(r.location)
+		endLayout := "15:04"
+		if peakEnd.YearDay() != peakStart.YearDay() || peakEnd.Year() != peakStart.Year() {
+			endLayout = "2006-01-02 15:04"
+		}
+		sb.WriteString(fmt.Sprintf("- **峰值時段**: %s 至 %s（%d 個錯誤）\n",
+			peakStart.Format("2006-01-02 15:04"), peakEnd.Format(endLayout), stats.TimeStats.PeakWindowCount))
+	}
+	if len(stats.TimeStats.HourlyDistribution) > 0 {
+		sb.WriteString(fmt.Sprintf("- **最繁忙時段（每日）**: %02d:00（%d 個錯誤）\n", stats.TimeStats.PeakHour, stats.TimeStats.PeakCount))
 	}
 
 	// Show top 2 most urgent problems
@@ -248,6 +323,64 @@ func (r *MarkdownReporter) writeTopProblemsSection(sb *strings.Builder, analyses
 			}
 		}
 
+		// Show exact first/last occurrence and the peak window
+		if group := r.groupFor(a); group != nil && !group.FirstSeen.IsZero() {
+			sb.WriteString(fmt.Sprintf("**首次 / 最後出現**: %s / %s  \n",
+				group.FirstSeen.In(r.location).Format("2006-01-02 15:04:05"),
+				group.LastSeen.In(r.location).Format("2006-01-02 15:04:05")))
+			if group.PeakWindow != nil {
+				sb.WriteString(fmt.Sprintf("**峰值窗口**: %s - %s（%d 個錯誤，%.1f 錯誤/分鐘）  \n",
+					group.PeakWindow.Start.In(r.location).Format("2006-01-02 15:04"),
+					group.PeakWindow.End.In(r.location).Format("15:04"),
+					group.PeakWindow.Count, group.PeakWindow.Density))
+			}
+		}
+
+		// Show when the rate shifted and whether it recovered
+		if group := r.groupFor(a); group != nil && len(group.Episodes) > 0 {
+			sb.WriteString(fmt.Sprintf("**異常時段**: %s  \n", r.formatEpisodes(group.Episodes)))
+		}
+
+		// Show errors relative to the service's volume
+		if group := r.groupFor(a); group != nil && group.ErrorRatio != nil {
+			sb.WriteString(fmt.Sprintf("**錯誤比率**: %s  \n", r.formatErrorRatio(group.ErrorRatio)))
+		}
+
+		// Show the deploy of the same service shortly before the group started
+		if group := r.groupFor(a); group != nil && group.Deploy != nil {
+			sb.WriteString(fmt.Sprintf("**部署關聯**: %s  \n", r.formatDeploy(group.Deploy)))
+		}
+
+		// Show the wrapped error chain, outermost first
+		if group := r.groupFor(a); group != nil && len(group.ErrorChain) > 1 {
+			sb.WriteString(fmt.Sprintf("**錯誤鏈**: `%s`  \n", strings.Join(group.ErrorChain, " → ")))
+		}
+
+		// Show how many near-duplicate variants were merged into this problem
+		if group := r.groupFor(a); group != nil && len(group.MergedFingerprints) > 0 {
+			sb.WriteString(fmt.Sprintf("**合併變體**: 已合併 %d 個相似變體  \n", len(group.MergedFingerprints)))
+		}
+
+		// Show how many distinct traces, pods, hosts and configured entities were affected
+		if group := r.groupFor(a); group != nil && len(group.DistinctCounts) > 0 {
+			sb.WriteString(fmt.Sprintf("**影響範圍**: %s  \n", formatTopCounts(group.DistinctCounts, len(group.DistinctCounts))))
+		}
+
+		// Show the most common values behind the placeholders
+		if group := r.groupFor(a); group != nil && len(group.MaskedValues) > 0 {
+			sb.WriteString(fmt.Sprintf("**常見值**: %s  \n", formatMaskedValues(group.MaskedValues, maxMaskedPositions, maxMaskedValues)))
+		}
+
+		// Show pod breakdown if Kubernetes metadata was available
+		if group := r.groupFor(a); group != nil && len(group.PodCounts) > 0 {
+			sb.WriteString(fmt.Sprintf("**Pod 分佈**: %s  \n", formatTopCounts(group.PodCounts, 5)))
+		}
+
+		// Compare the rate with the group's baseline from previous runs
+		if a.TrendAnalysis != nil {
+			sb.WriteString(fmt.Sprintf("**趨勢**: %s  \n", formatTrend(a.TrendAnalysis)))
+		}
+
 		// Determine time pattern
 		pattern := determineTimePattern(a, stats)
 		sb.WriteString(fmt.Sprintf("**時間模式**: %s  \n", pattern))
@@ -257,7 +390,7 @@ func (r *MarkdownReporter) writeTopProblemsSection(sb *strings.Builder, analyses
 		sb.WriteString(fmt.Sprintf("**嚴重性**: 🔴 **%s** - %s  \n", strings.ToUpper(string(a.Severity)), severityReason))
 
 		// Engineering suggestion
-		suggestion := deriveEngineeringSuggestion(a, pattern)
+		suggestion := deriveEngineeringSuggestion(a, r.groupFor(a), pattern)
 		sb.WriteString(fmt.Sprintf("**下一步**: %s\n\n", suggestion))
 	}
 
@@ -304,6 +437,138 @@ func (r *MarkdownReporter) writeSecondaryIssuesSection(sb *strings.Builder, anal
 	sb.WriteString("\n")
 }
 
+// writeParseFailuresSection writes the summary of logs rejected during preprocessing
+func (r *MarkdownReporter) writeParseFailuresSection(sb *strings.Builder, stats *interfaces.AggregationResult) {
+	failures := stats.ParseFailures
+	if failures == nil {
+		return
+	}
+
+	sb.WriteString("## ⚠️ 解析失敗\n\n")
+
+	if failures.Failed == 0 {
+		sb.WriteString(fmt.Sprintf("全部 %d 條原始日誌均成功解析。\n\n", failures.TotalRawLogs))
+		return
+	}
+
+	rate := float64(failures.Failed) / float64(failures.TotalRawLogs) * 100
+	sb.WriteString(fmt.Sprintf("**失敗數**: %d / %d 條原始日誌（%.1f%%）  \n", failures.Failed, failures.TotalRawLogs, rate))
+	if failures.DeadLetterPath != "" {
+		sb.WriteString(fmt.Sprintf("**隔離檔案**: `%s`\n\n", failures.DeadLetterPath))
+	}
+
+	reasons := make([]string, 0, len(failures.Reasons))
+	for reason := range failures.Reasons {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		return failures.Reasons[reasons[i]] > failures.Reasons[reasons[j]]
+	})
+
+	sb.WriteString("| 失敗原因 | 次數 |\n")
+	sb.WriteString("|---------|------|\n")
+	for _, reason := range reasons {
+		sb.WriteString(fmt.Sprintf("| %s | %d |\n", rejectReasonLabel(reason), failures.Reasons[reason]))
+	}
+
+	sb.WriteString("\n")
+}
+
+// writeClockSkewSection lists pods whose application clock is skewed against the runtime/shipper clock
+func (r *MarkdownReporter) writeClockSkewSection(sb *strings.Builder, stats *interfaces.AggregationResult) {
+	var flagged []interfaces.PodClockSkew
+	for _, skew := range stats.ClockSkew {
+		if skew.Flagged {
+			flagged = append(flagged, skew)
+		}
+	}
+	if len(flagged) == 0 {
+		return
+	}
+
+	sb.WriteString("## ⏱️ 時鐘偏移\n\n")
+	sb.WriteString("以下 Pod 的應用時間戳與容器執行環境時間戳差異過大，其錯誤時間可能不準確：\n\n")
+	sb.WriteString("| Pod | 節點 | 樣本數 | 偏移中位數 | 最大偏移 |\n")
+	sb.WriteString("|-----|------|-------|----------|---------|\n")
+	for _, skew := range flagged {
+		sb.WriteString(fmt.Sprintf("| `%s` | %s | %d | %s | %s |\n",
+			skew.Pod, skew.Node, skew.Samples,
+			skew.MedianSkew.Round(time.Second), skew.MaxSkew.Round(time.Second)))
+	}
+
+	sb.WriteString("\n")
+}
+
+// maxRootCauses limits the rows of the root-cause table
+const maxRootCauses = 10
+
+// writeRootCauseSection lists the most frequent root causes across callers and services
+func (r *MarkdownReporter) writeRootCauseSection(sb *strings.Builder, stats *interfaces.AggregationResult) {
+	if len(stats.RootCauses) == 0 {
+		return
+	}
+
+	sb.WriteString("## 🧬 根因分組\n\n")
+	sb.WriteString("依錯誤鏈最內層原因合併不同呼叫位置與服務的錯誤：\n\n")
+	sb.WriteString("| 根因 | 次數 | 錯誤模式數 | 服務 | 呼叫位置數 |\n")
+	sb.WriteString("|------|------|-----------|------|-----------|\n")
+	for i, rc := range stats.RootCauses {
+		if i >= maxRootCauses {
+			break
+		}
+		sb.WriteString(fmt.Sprintf("| `%s` | %d | %d | %s | %d |\n",
+			rc.RootCause, rc.TotalCount, len(rc.Fingerprints),
+			strings.Join(rc.Services, ", "), len(rc.Callers)))
+	}
+
+	sb.WriteString("\n")
+}
+
+// maxCascades limits the cascade chains shown in a report
+const maxCascades = 5
+
+// writeCascadeSection renders cascade chains: origin error group followed by its downstream effects
+func (r *MarkdownReporter) writeCascadeSection(sb *strings.Builder, stats *interfaces.AggregationResult) {
+	if len(stats.Cascades) == 0 {
+		return
+	}
+
+	sb.WriteString("## 🔗 跨服務連鎖錯誤\n\n")
+	sb.WriteString("以下錯誤在相同 trace 中先後出現，起源錯誤可能觸發了下游服務的錯誤：\n\n")
+	for i, chain := range stats.Cascades {
+		if i >= maxCascades {
+			break
+		}
+
+		sb.WriteString(fmt.Sprintf("**%d. 起源** `%s` (%s): `%s`\n",
+			i+1, chain.OriginService, models.ShortFingerprint(chain.OriginFingerprint), chain.OriginContent))
+		for _, effect := range chain.Effects {
+			indent := strings.Repeat("  ", effect.Depth)
+			sb.WriteString(fmt.Sprintf("%s- → `%s` (%s): `%s`（共享 %d 個 trace，延遲中位數 %s）\n",
+				indent, effect.Service, models.ShortFingerprint(effect.Fingerprint), effect.Content,
+				effect.SharedTraces, effect.MedianLag.Round(time.Millisecond)))
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// rejectReasonLabel returns the display label for a preprocessing rejection reason
+func rejectReasonLabel(reason string) string {
+	labels := map[string]string{
+		"no_message":       "缺少訊息內容",
+		"wrapper_mismatch": "Wrapper 格式不符",
+		"bad_json":         "JSON 解析失敗",
+		"invalid_level":    "無效的日誌級別",
+		"no_service":       "無法識別服務",
+		"missing_field":    "缺少必要欄位",
+		"bad_timestamp":    "時間戳無法解析",
+	}
+	if label, ok := labels[reason]; ok {
+		return fmt.Sprintf("%s (`%s`)", label, reason)
+	}
+	return reason
+}
+
 // extractErrorMessage extracts the error message from action text
 func extractErrorMessage(action string) string {
 	// Extract from "Investigate error pattern: <message>"
@@ -328,28 +593,86 @@ func extractLocation(action string) string {
 	return action
 }
 
-// writeTimeDistributionSection writes the time distribution chart
+// maxTimelineHours is the longest range charted hour by hour; longer ranges are charted by day
+const maxTimelineHours = 48
+
+// weekdayNames are the weekday labels of the weekday profile, Monday first
+var weekdayNames = []struct {
+	day  time.Weekday
+	name string
+}{
+	{time.Monday, "週一"}, {time.Tuesday, "週二"}, {time.Wednesday, "週三"}, {time.Thursday, "週四"},
+	{time.Friday, "週五"}, {time.Saturday, "週六"}, {time.Sunday, "週日"},
+}
+
+// writeTimeDistributionSection writes the absolute timeline and the hour-of-day and weekday profiles
 func (r *MarkdownReporter) writeTimeDistributionSection(sb *strings.Builder, stats *interfaces.AggregationResult) {
-	sb.WriteString("## Time Distribution\n\n")
-	sb.WriteString("```\n")
+	if len(stats.TimeStats.Timeline) == 0 {
+		return
+	}
+
+	sb.WriteString("## 🕒 時間分佈\n\n")
+
+	// Absolute timeline: by hour for short ranges, by day otherwise
+	var labels []string
+	var counts []int
+	if len(stats.TimeStats.Timeline) <= maxTimelineHours {
+		sb.WriteString("**時間軸（每小時）**:\n\n")
+		for _, bucket := range stats.TimeStats.Timeline {
+			labels = append(labels, bucket.Start.In(r.location).Format("01-02 15:04"))
+			counts = append(counts, bucket.Count)
+		}
+	} else {
+		sb.WriteString("**時間軸（每日）**:\n\n")
+		for _, bucket := range stats.TimeStats.Timeline {
+			day := bucket.Start.In(r.location).Format("2006-01-02 (Mon)")
+			if len(labels) == 0 || labels[len(labels)-1] != day {
+				labels = append(labels, day)
+				counts = append(counts, 0)
+			}
+			counts[len(counts)-1] += bucket.Count
+		}
+	}
+	writeBarChart(sb, labels, counts)
+
+	// Hour-of-day profile, across days
+	labels, counts = nil, nil
+	for hour := 0; hour < 24; hour++ {
+		labels = append(labels, fmt.Sprintf("%02d:00", hour))
+		counts = append(counts, stats.TimeStats.HourlyDistribution[hour])
+	}
+	sb.WriteString("**每日時段分佈**:\n\n")
+	writeBarChart(sb, labels, counts)
+
+	// Weekday profile, only meaningful when the range spans several days
+	if stats.TimeStats.QueryDuration >= 48*time.Hour {
+		labels, counts = nil, nil
+		for _, w := range weekdayNames {
+			labels = append(labels, w.name)
+			counts = append(counts, stats.TimeStats.DayOfWeekDistribution[w.day])
+		}
+		sb.WriteString("**星期分佈**:\n\n")
+		writeBarChart(sb, labels, counts)
+	}
+}
 
-	// Create simple ASCII chart
+// writeBarChart writes a fenced ASCII bar chart, bars scaled to the largest count
+func writeBarChart(sb *strings.Builder, labels []string, counts []int) {
 	maxCount := 0
-	for _, count := range stats.TimeStats.HourlyDistribution {
+	for _, count := range counts {
 		if count > maxCount {
 			maxCount = count
 		}
 	}
 
-	if maxCount > 0 {
-		for hour := 0; hour < 24; hour++ {
-			count := stats.TimeStats.HourlyDistribution[hour]
-			barLength := (count * 40) / maxCount
-			bar := strings.Repeat("█", barLength)
-			sb.WriteString(fmt.Sprintf("%02d:00 | %s %d\n", hour, bar, count))
+	sb.WriteString("```\n")
+	for i, label := range labels {
+		barLength := 0
+		if maxCount > 0 {
+			barLength = (counts[i] * 40) / maxCount
 		}
+		sb.WriteString(fmt.Sprintf("%s | %s %d\n", label, strings.Repeat("█", barLength), counts[i]))
 	}
-
 	sb.WriteString("```\n\n")
 }
 
@@ -358,21 +681,33 @@ func (r *MarkdownReporter) writeTimeDistributionSection(sb *strings.Builder, sta
 
 // Helper functions
 
+// sortBySeverity orders analyses by severity. Groups flagged as anomalous against their
+// baseline rank right after high severity, so a small but unusual spike is not buried.
 func sortBySeverity(analyses []models.Analysis) []models.Analysis {
 	sorted := make([]models.Analysis, len(analyses))
 	copy(sorted, analyses)
-	sort.Slice(sorted, func(i, j int) bool {
-		severityRank := map[models.Severity]int{
-			models.SeverityCritical: 0,
-			models.SeverityHigh:     1,
-			models.SeverityMedium:   2,
-			models.SeverityLow:      3,
-		}
-		return severityRank[sorted[i].Severity] < severityRank[sorted[j].Severity]
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return severityRank(sorted[i]) < severityRank(sorted[j])
 	})
 	return sorted
 }
 
+func severityRank(a models.Analysis) int {
+	switch a.Severity {
+	case models.SeverityCritical:
+		return 0
+	case models.SeverityHigh:
+		return 1
+	}
+	if a.TrendAnalysis != nil && a.TrendAnalysis.IsAnomalous {
+		return 2
+	}
+	if a.Severity == models.SeverityMedium {
+		return 3
+	}
+	return 4
+}
+
 func extractProblemName(a models.Analysis) string {
 	// Extract from reason or error message
 	if len(a.SuggestedActions) > 0 {
@@ -425,7 +760,11 @@ func calculateSeverityReason(a models.Analysis, count string) string {
 	return "低影響，可以延後處理"
 }
 
-func deriveEngineeringSuggestion(a models.Analysis, pattern string) string {
+func deriveEngineeringSuggestion(a models.Analysis, group *models.ErrorGroup, pattern string) string {
+	if group != nil && group.Deploy != nil {
+		event := group.Deploy.Event
+		return fmt.Sprintf("比對 %s %s 的變更內容，確認是否需要回滾", event.Service, deployVersion(event))
+	}
 	if strings.Contains(pattern, "爆發型") {
 		return "檢查峰值時段附近的最近部署或流量變化"
 	} else if strings.Contains(pattern, "持續型") {
@@ -435,7 +774,7 @@ func deriveEngineeringSuggestion(a models.Analysis, pattern string) string {
 }
 
 // SaveAnalysisJSON saves analysis results as JSON for further processing
-func SaveAnalysisJSON(analyses []models.Analysis, stats *interfaces.AggregationResult, outputPath string) error {
+func SaveAnalysisJSON(analyses []models.Analysis, errorGroups []models.ErrorGroup, stats *interfaces.AggregationResult, outputPath string) error {
 	// Create output directory
 	if err := os.MkdirAll(outputPath, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
@@ -443,10 +782,11 @@ func SaveAnalysisJSON(analyses []models.Analysis, stats *interfaces.AggregationR
 
 	// Prepare data structure
 	data := map[string]interface{}{
-		"timestamp":   time.Now(),
-		"analyses":    analyses,
-		"aggregation": stats,
-		"agg_stats":   aggregator.GetAggregationStats(stats),
+		"timestamp":    time.Now(),
+		"analyses":     analyses,
+		"error_groups": errorGroups,
+		"aggregation":  stats,
+		"agg_stats":    aggregator.GetAggregationStats(stats),
 	}
 
 	// Marshal to JSON
@@ -508,6 +848,189 @@ func formatDuration(d time.Duration) string {
 	return "過去 0 分鐘"
 }
 
+// maxEpisodes is the number of rate shifts listed per group or service
+const maxEpisodes = 3
+
+// formatEpisodes renders rate shifts, e.g. "開始 2025-03-03 14:07，速率 ×12.0（4.8 錯誤/分鐘），恢復 14:40"
+func (r *MarkdownReporter) formatEpisodes(episodes []models.Episode) string {
+	parts := make([]string, 0, maxEpisodes)
+	for i, e := range episodes {
+		if i == maxEpisodes {
+			parts = append(parts, fmt.Sprintf("另有 %d 段", len(episodes)-maxEpisodes))
+			break
+		}
+
+		onset := e.Onset.In(r.location)
+		rate := fmt.Sprintf("新出現（%.1f 錯誤/分鐘）", e.Rate)
+		if ratio := e.RateRatio(); ratio > 0 {
+			rate = fmt.Sprintf("速率 ×%.1f（%.1f 錯誤/分鐘）", ratio, e.Rate)
+		}
+		recovery := "尚未恢復"
+		if e.Recovered {
+			end := e.Recovery.In(r.location)
+			layout := "15:04"
+			if end.YearDay() != onset.YearDay() || end.Year() != onset.Year() {
+				layout = "2006-01-02 15:04"
+			}
+			recovery = "恢復 " + end.Format(layout)
+		}
+		parts = append(parts, fmt.Sprintf("開始 %s，%s，%s", onset.Format("2006-01-02 15:04"), rate, recovery))
+	}
+	return strings.Join(parts, "；")
+}
+
+// formatDeploy renders a deploy match, e.g. "pp-slot-api v1.4.2 於 2025-03-03 14:03 部署，4 分鐘後開始"
+func (r *MarkdownReporter) formatDeploy(match *models.DeployMatch) string {
+	event := match.Event
+	text := fmt.Sprintf("%s %s 於 %s 部署，%d 分鐘後開始", event.Service, deployVersion(event),
+		event.Time.In(r.location).Format("2006-01-02 15:04"), int(match.Lag.Minutes()+0.5))
+	if event.Description != "" {
+		text += fmt.Sprintf("（%s）", event.Description)
+	}
+	return text
+}
+
+// deployVersion returns the deployed version, or a placeholder when the event has none
+func deployVersion(event models.DeployEvent) string {
+	if event.Version == "" {
+		return "（未知版本）"
+	}
+	return event.Version
+}
+
+// countFiringBurnRates counts firing burn-rate alerts of high (or critical) and of lower severity
+func countFiringBurnRates(stats *interfaces.AggregationResult) (page, ticket int) {
+	for _, serviceStats := range stats.ServiceStats {
+		for _, rate := range serviceStats.BurnRates {
+			if !rate.Firing {
+				continue
+			}
+			if rate.Severity == models.SeverityHigh || rate.Severity == models.SeverityCritical {
+				page++
+			} else {
+				ticket++
+			}
+		}
+	}
+	return page, ticket
+}
+
+// formatErrorRatio renders an error ratio, e.g. "0.42%（1234 / 293000），每小時峰值 2.10%（2025-03-03 04:00）"
+func (r *MarkdownReporter) formatErrorRatio(ratio *models.ErrorRatio) string {
+	text := fmt.Sprintf("%s（%d / %d）", formatPercent(ratio.Ratio), ratio.Errors, ratio.Total)
+	if !ratio.PeakHour.IsZero() {
+		text += fmt.Sprintf("，每小時峰值 %s（%s）", formatPercent(ratio.PeakRatio), ratio.PeakHour.In(r.location).Format("2006-01-02 15:04"))
+	}
+	return text
+}
+
+// formatBurnRates renders burn rates per window, marking firing alerts, e.g. "1h ×15.2 🔥 · 6h ×4.1"
+func formatBurnRates(rates []models.BurnRate) string {
+	parts := make([]string, 0, len(rates))
+	for _, rate := range rates {
+		part := fmt.Sprintf("%s ×%.1f", formatWindow(rate.Window), rate.Rate)
+		if rate.Firing {
+			part += fmt.Sprintf(" 🔥（%s ×%.1f，門檻 ×%.1f）", formatWindow(rate.ShortWindow), rate.ShortRate, rate.Threshold)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// formatWindow renders a burn-rate window as "5m", "1h" or "1h30m"
+func formatWindow(d time.Duration) string {
+	text := d.String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
+
+// formatPercent renders a fraction as a percentage with enough precision for SLO targets
+func formatPercent(fraction float64) string {
+	text := strconv.FormatFloat(fraction*100, 'f', 3, 64)
+	text = strings.TrimRight(strings.TrimRight(text, "0"), ".")
+	return text + "%"
+}
+
+// countAnomalous counts analyses whose rate is anomalous against their baseline
+func countAnomalous(analyses []models.Analysis) int {
+	count := 0
+	for _, a := range analyses {
+		if a.TrendAnalysis != nil && a.TrendAnalysis.IsAnomalous {
+			count++
+		}
+	}
+	return count
+}
+
+// formatTrend renders the hourly rate against its baseline, e.g.
+// "📈 異常上升 - 每小時 12.0 次，基準 1.5 次（每週同時段，+700%，z=8.2）"
+func formatTrend(t *models.TrendAnalysis) string {
+	method := "EWMA"
+	if t.Method == trend.MethodSeasonal {
+		method = "每週同時段"
+	}
+	detail := fmt.Sprintf("每小時 %.1f 次，基準 %.1f 次（%s，%+.0f%%，z=%.1f）",
+		t.ObservedRate, t.BaselineRate, method, t.PercentageChange, t.ZScore)
+	if t.IsAnomalous {
+		return "📈 異常上升 - " + detail
+	}
+	return detail
+}
+
+// formatTopCounts formats the highest counts as "a (12)、b (3)", summarizing the rest
+func formatTopCounts(counts map[string]int, limit int) string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	parts := make([]string, 0, limit+1)
+	for i, key := range keys {
+		if i == limit {
+			parts = append(parts, fmt.Sprintf("其他 %d 個", len(keys)-limit))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("`%s` (%d)", key, counts[key]))
+	}
+	return strings.Join(parts, "、")
+}
+
+// Limits of the "most common values" line: positions shown, and values per position
+const (
+	maxMaskedPositions = 3
+	maxMaskedValues    = 3
+)
+
+// formatMaskedValues formats the most common values of the first positions, e.g. "`<NUM>#1`: `42` (10)、`7` (3)"
+func formatMaskedValues(stats []models.MaskedValueStats, positions, values int) string {
+	parts := make([]string, 0, positions)
+	for i, s := range stats {
+		if i == positions {
+			break
+		}
+		top := make([]string, 0, values)
+		for j, v := range s.TopValues {
+			if j == values {
+				break
+			}
+			top = append(top, fmt.Sprintf("`%s` (%d)", v.Value, v.Count))
+		}
+		parts = append(parts, fmt.Sprintf("`%s`: %s", s.Position, strings.Join(top, "、")))
+	}
+	return strings.Join(parts, "；")
+}
+
 // countNewIssues counts the number of new unknown issues
 func countNewIssues(analyses []models.Analysis) int {
 	count := 0
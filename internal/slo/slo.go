@@ -0,0 +1,150 @@
+package slo
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"log-analyzer/pkg/models"
+)
+
+// Window is a multi-window burn-rate alert: it fires when both the long and the short window
+// burn the error budget at least BurnRate times faster than sustainable
+type Window struct {
+	Long     time.Duration
+	Short    time.Duration
+	BurnRate float64
+	Severity models.Severity
+}
+
+// Config contains SLO and error-ratio settings
+type Config struct {
+	Target    float64 // SLO target, e.g. 0.999 leaves an error budget of 0.1%
+	Windows   []Window
+	MinVolume int // hours with less total volume are ignored for the peak hourly ratio
+}
+
+// Ratio relates errors to totals over the whole series and per hour
+func Ratio(errors, totals []models.TimeBucket, minVolume int) *models.ErrorRatio {
+	total := sum(totals)
+	if total == 0 {
+		return nil
+	}
+
+	ratio := &models.ErrorRatio{Errors: sum(errors), Total: total}
+	ratio.Ratio = float64(ratio.Errors) / float64(total)
+
+	hourlyErrors := hourly(errors)
+	hourlyTotals := hourly(totals)
+	hours := make([]time.Time, 0, len(hourlyTotals))
+	for hour := range hourlyTotals {
+		hours = append(hours, hour)
+	}
+	sort.Slice(hours, func(i, j int) bool {
+		return hours[i].Before(hours[j])
+	})
+	for _, hour := range hours {
+		if hourlyTotals[hour] < minVolume || hourlyTotals[hour] == 0 {
+			continue
+		}
+		if r := float64(hourlyErrors[hour]) / float64(hourlyTotals[hour]); r > ratio.PeakRatio {
+			ratio.PeakRatio = r
+			ratio.PeakHour = hour
+		}
+	}
+	return ratio
+}
+
+// BurnRates computes the burn rate of each window ending at end. Windows without volume are skipped.
+func BurnRates(errors, totals []models.TimeBucket, end time.Time, config Config) []models.BurnRate {
+	budget := 1 - config.Target
+	if budget <= 0 {
+		return nil
+	}
+
+	var rates []models.BurnRate
+	for _, window := range config.Windows {
+		long, ok := windowRatio(errors, totals, end.Add(-window.Long), end)
+		if !ok {
+			continue
+		}
+		short, _ := windowRatio(errors, totals, end.Add(-window.Short), end)
+
+		rate := models.BurnRate{
+			Window:      window.Long,
+			ShortWindow: window.Short,
+			Rate:        long / budget,
+			ShortRate:   short / budget,
+			Threshold:   window.BurnRate,
+			Target:      config.Target,
+			Severity:    window.Severity,
+		}
+		rate.Firing = rate.Rate >= window.BurnRate && rate.ShortRate >= window.BurnRate
+		rates = append(rates, rate)
+	}
+	return rates
+}
+
+// Scale estimates the full counts of a series whose logs were fetched with a per-window cap.
+// Each bucket is multiplied by exact / fetched of the interval bucket it falls in, where fetched
+// is the service's series built from fetched logs and exact its aggregated error counts.
+// Buckets are never scaled down.
+func Scale(series, fetched, exact []models.TimeBucket, interval time.Duration) []models.TimeBucket {
+	fetchedCounts := byInterval(fetched, interval)
+	exactCounts := byInterval(exact, interval)
+
+	scaled := make([]models.TimeBucket, len(series))
+	for i, bucket := range series {
+		scaled[i] = bucket
+		key := bucket.Start.UTC().Truncate(interval)
+		if got, want := fetchedCounts[key], exactCounts[key]; got > 0 && want > got {
+			scaled[i].Count = int(math.Round(float64(bucket.Count) * float64(want) / float64(got)))
+		}
+	}
+	return scaled
+}
+
+// byInterval sums a series into buckets of interval
+func byInterval(series []models.TimeBucket, interval time.Duration) map[time.Time]int {
+	counts := make(map[time.Time]int)
+	for _, bucket := range series {
+		counts[bucket.Start.UTC().Truncate(interval)] += bucket.Count
+	}
+	return counts
+}
+
+// windowRatio returns the error ratio of buckets starting in [start, end); false without volume
+func windowRatio(errors, totals []models.TimeBucket, start, end time.Time) (float64, bool) {
+	total := sumBetween(totals, start, end)
+	if total == 0 {
+		return 0, false
+	}
+	return float64(sumBetween(errors, start, end)) / float64(total), true
+}
+
+func sumBetween(series []models.TimeBucket, start, end time.Time) int {
+	count := 0
+	for _, bucket := range series {
+		if !bucket.Start.Before(start) && bucket.Start.Before(end) {
+			count += bucket.Count
+		}
+	}
+	return count
+}
+
+func sum(series []models.TimeBucket) int {
+	count := 0
+	for _, bucket := range series {
+		count += bucket.Count
+	}
+	return count
+}
+
+// hourly sums a series into UTC hours
+func hourly(series []models.TimeBucket) map[time.Time]int {
+	counts := make(map[time.Time]int)
+	for _, bucket := range series {
+		counts[bucket.Start.UTC().Truncate(time.Hour)] += bucket.Count
+	}
+	return counts
+}
@@ -0,0 +1,97 @@
+package slo
+
+import (
+	"testing"
+	"time"
+
+	"log-analyzer/pkg/models"
+)
+
+// series returns count per bucket from start for n buckets
+func series(start time.Time, bucket time.Duration, n int, count func(i int) int) []models.TimeBucket {
+	var buckets []models.TimeBucket
+	for i := 0; i < n; i++ {
+		buckets = append(buckets, models.TimeBucket{Start: start.Add(time.Duration(i) * bucket), Count: count(i)})
+	}
+	return buckets
+}
+
+func TestRatioPeakHourNeedsVolume(t *testing.T) {
+	start := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	// Busy hours have 12000 requests, 04:00 only 600, 05:00 only 60
+	totals := series(start, 5*time.Minute, 12*24, func(i int) int {
+		switch i / 12 {
+		case 4:
+			return 50
+		case 5:
+			return 5
+		}
+		return 1000
+	})
+	// 60 errors every hour: 0.5% of a busy hour, 10% at 04:00 and all requests at 05:00
+	errors := series(start, time.Minute, 60*24, func(int) int { return 1 })
+
+	ratio := Ratio(errors, totals, 100)
+	if ratio.Errors != 1440 || ratio.Total != 22*12000+600+60 {
+		t.Fatalf("ratio = %+v", ratio)
+	}
+	if !ratio.PeakHour.Equal(start.Add(4*time.Hour)) || ratio.PeakRatio != 0.1 {
+		t.Errorf("peak = %.3f at %s, want 0.1 at 04:00 (05:00 is below min volume)", ratio.PeakRatio, ratio.PeakHour)
+	}
+
+	if Ratio(errors, nil, 100) != nil {
+		t.Error("ratio without volume should be nil")
+	}
+}
+
+func TestBurnRates(t *testing.T) {
+	start := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	totals := series(start, 5*time.Minute, 12*24, func(int) int { return 1000 })
+	// 0.05% errors all day, 2% in the last hour
+	errors := series(start, 5*time.Minute, 12*24, func(i int) int {
+		if i >= 12*23 {
+			return 20
+		}
+		return 0
+	})
+	for i := 0; i < len(errors); i += 2 {
+		errors[i].Count++
+	}
+
+	config := Config{Target: 0.999, Windows: []Window{
+		{Long: time.Hour, Short: 5 * time.Minute, BurnRate: 14.4, Severity: models.SeverityHigh},
+		{Long: 24 * time.Hour, Short: 2 * time.Hour, BurnRate: 3, Severity: models.SeverityMedium},
+	}}
+	rates := BurnRates(errors, totals, end, config)
+	if len(rates) != 2 {
+		t.Fatalf("got %d burn rates, want 2", len(rates))
+	}
+	if !rates[0].Firing || rates[0].Rate < 20 {
+		t.Errorf("1h burn rate = %+v, want firing at about 20.5×", rates[0])
+	}
+	if rates[1].Firing {
+		t.Errorf("24h burn rate = %+v, want not firing", rates[1])
+	}
+
+	// Windows without volume are skipped
+	if rates := BurnRates(errors, nil, end, config); len(rates) != 0 {
+		t.Errorf("burn rates without volume = %+v", rates)
+	}
+}
+
+func TestScaleRestoresCappedCounts(t *testing.T) {
+	start := time.Date(2025, 3, 3, 14, 0, 0, 0, time.UTC)
+	// One group holds a quarter of the fetched errors; the second interval was capped at 500 of 2000
+	group := series(start, time.Minute, 10, func(int) int { return 25 })
+	fetched := series(start, 5*time.Minute, 2, func(int) int { return 500 })
+	exact := series(start, 5*time.Minute, 2, func(i int) int { return []int{500, 2000}[i] })
+
+	scaled := Scale(group, fetched, exact, 5*time.Minute)
+	if scaled[0].Count != 25 || scaled[9].Count != 100 {
+		t.Errorf("scaled = %+v, want 25 per minute before the cap and 100 after", scaled)
+	}
+	if sum(scaled) != 125+500 {
+		t.Errorf("scaled total = %d, want 625", sum(scaled))
+	}
+}
@@ -0,0 +1,177 @@
+package trend
+
+import (
+	"math"
+	"time"
+
+	"log-analyzer/pkg/models"
+)
+
+// Baseline methods recorded in TrendAnalysis.Method
+const (
+	MethodSeasonal = "seasonal" // hour-of-week average
+	MethodEWMA     = "ewma"     // exponentially weighted moving average
+)
+
+// minSeasonalSamples is the number of past weeks each hour-of-week needs before the seasonal baseline is used
+const minSeasonalSamples = 2
+
+// minExpectedRate keeps percentage changes finite for groups with no history
+const minExpectedRate = 0.1
+
+// Config contains baseline and anomaly settings
+type Config struct {
+	AnomalyThreshold float64 // z-score at or above which a rate is anomalous
+	MinPercentChange float64 // minimum increase over the baseline, in percent
+	MinCount         int     // minimum count in the run before a group can be anomalous
+	EWMAAlpha        float64 // smoothing factor of the EWMA baseline
+}
+
+// Detector compares the hourly rate of a run against baselines built from the history
+type Detector struct {
+	history *History
+	config  Config
+}
+
+// NewDetector creates a detector over the given history
+func NewDetector(history *History, config Config) *Detector {
+	return &Detector{history: history, config: config}
+}
+
+// AnalyzeGroup compares a group's rate between start and end against its baseline.
+// Returns nil when the history has no hours before start.
+func (d *Detector) AnalyzeGroup(group models.ErrorGroup, start, end time.Time) *models.TrendAnalysis {
+	return d.analyze(d.history.GroupSeries(group.Fingerprint), group.TotalCount, start, end)
+}
+
+// AnalyzeService compares a service's rate between start and end against its baseline
+func (d *Detector) AnalyzeService(service string, total int, start, end time.Time) *models.TrendAnalysis {
+	return d.analyze(d.history.ServiceSeries(service), total, start, end)
+}
+
+// analyze builds the baselines of a series and scores the observed rate against them
+func (d *Detector) analyze(series map[time.Time]int, total int, start, end time.Time) *models.TrendAnalysis {
+	runHours := HoursBetween(start, end)
+	past := d.history.ObservedBefore(runHours[0])
+	if len(past) == 0 {
+		return nil
+	}
+
+	values := make([]float64, len(past))
+	for i, hour := range past {
+		values[i] = float64(series[hour])
+	}
+	mean, stddev := meanStdDev(values)
+	ewma := ewma(values, d.config.EWMAAlpha)
+
+	trend := &models.TrendAnalysis{
+		ObservedRate: float64(total) / float64(len(runHours)),
+		Mean:         mean,
+		StdDev:       stddev,
+		EWMA:         ewma,
+		BaselineRate: ewma,
+		Method:       MethodEWMA,
+	}
+	if seasonal, ok := seasonalRate(series, past, runHours); ok {
+		trend.Seasonal = seasonal
+		trend.BaselineRate = seasonal
+		trend.Method = MethodSeasonal
+	}
+
+	dayStart := runHours[0].Add(-24 * time.Hour)
+	for _, hour := range past {
+		if !hour.Before(dayStart) {
+			trend.PreviousDayCount += series[hour]
+		}
+	}
+
+	// Score the run's count against the spread of counts over past windows of the same length,
+	// so a run of many hours is not compared with the noise of a single hour
+	runLength := len(runHours)
+	expected := trend.BaselineRate * float64(runLength)
+	trend.ZScore = (float64(total) - expected) / windowStdDev(values, runLength, expected)
+
+	diff := trend.ObservedRate - trend.BaselineRate
+	trend.PercentageChange = diff / math.Max(trend.BaselineRate, minExpectedRate) * 100
+	trend.IsAnomalous = total >= d.config.MinCount &&
+		trend.ZScore >= d.config.AnomalyThreshold &&
+		trend.PercentageChange >= d.config.MinPercentChange
+
+	return trend
+}
+
+// seasonalRate averages the past counts of each run hour's hour-of-week.
+// It reports false unless every run hour has enough past samples.
+func seasonalRate(series map[time.Time]int, past, runHours []time.Time) (float64, bool) {
+	sums := make(map[int]float64)
+	samples := make(map[int]int)
+	for _, hour := range past {
+		slot := hourOfWeek(hour)
+		sums[slot] += float64(series[hour])
+		samples[slot]++
+	}
+
+	var total float64
+	for _, hour := range runHours {
+		slot := hourOfWeek(hour)
+		if samples[slot] < minSeasonalSamples {
+			return 0, false
+		}
+		total += sums[slot] / float64(samples[slot])
+	}
+	return total / float64(len(runHours)), true
+}
+
+// hourOfWeek returns the UTC hour-of-week slot of t (0 = Sunday 00:00)
+func hourOfWeek(t time.Time) int {
+	t = t.UTC()
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// windowStdDev returns the standard deviation of counts over windows of length hours, from
+// consecutive past windows (most recent first) or, with fewer than two, from the hourly spread.
+// It is floored at the Poisson noise of the expected count and at one error, so a sparse
+// series does not flag a single extra error.
+func windowStdDev(values []float64, length int, expected float64) float64 {
+	var sums []float64
+	for end := len(values); end-length >= 0; end -= length {
+		var sum float64
+		for _, v := range values[end-length : end] {
+			sum += v
+		}
+		sums = append(sums, sum)
+	}
+
+	var stddev float64
+	if len(sums) >= 2 {
+		_, stddev = meanStdDev(sums)
+	} else {
+		_, hourly := meanStdDev(values)
+		stddev = hourly * math.Sqrt(float64(length))
+	}
+	return math.Max(stddev, math.Max(math.Sqrt(expected), 1))
+}
+
+// meanStdDev returns the mean and population standard deviation of values
+func meanStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+// ewma returns the exponentially weighted moving average of values, oldest first
+func ewma(values []float64, alpha float64) float64 {
+	avg := values[0]
+	for _, v := range values[1:] {
+		avg = alpha*v + (1-alpha)*avg
+	}
+	return avg
+}
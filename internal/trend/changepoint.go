@@ -0,0 +1,154 @@
+package trend
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"log-analyzer/pkg/models"
+)
+
+// ChangePointConfig contains the CUSUM settings of change-point detection
+type ChangePointConfig struct {
+	Threshold float64 // decision interval, in baseline standard deviations
+	Drift     float64 // slack above the baseline tolerated before evidence accumulates, in standard deviations
+	MinCount  int     // episodes with fewer errors are dropped
+}
+
+// ChangePointDetector finds onset and recovery of rate shifts in bucketed time series
+type ChangePointDetector struct {
+	bucket time.Duration
+	config ChangePointConfig
+}
+
+// NewChangePointDetector creates a detector for time series with the given bucket size
+func NewChangePointDetector(bucket time.Duration, config ChangePointConfig) *ChangePointDetector {
+	return &ChangePointDetector{bucket: bucket, config: config}
+}
+
+// Detect runs a two-sided CUSUM over series between start and end, with empty buckets as zeros.
+// The baseline is the median bucket count; an upward CUSUM crossing the threshold opens an
+// episode at the bucket where it started rising, and a downward CUSUM crossing it closes the
+// episode at the bucket where the rate dropped back.
+func (d *ChangePointDetector) Detect(series []models.TimeBucket, start, end time.Time) []models.Episode {
+	counts := d.dense(series, start, end)
+	if len(counts) == 0 {
+		return nil
+	}
+	first := start.Truncate(d.bucket)
+
+	baseline := median(counts)
+	// Error counts are roughly Poisson; the floor keeps sparse series from alarming on single errors
+	sigma := math.Max(math.Sqrt(baseline), 1)
+	reference := baseline + d.config.Drift*sigma
+	limit := d.config.Threshold * sigma
+
+	var episodes []models.Episode
+	var up, down float64
+	var rising, falling, onset int
+	inEpisode := false
+
+	for i, count := range counts {
+		value := float64(count)
+		if !inEpisode {
+			if up == 0 {
+				rising = i
+			}
+			up = math.Max(0, up+value-reference)
+			if up > limit {
+				inEpisode = true
+				onset = rising
+				down = 0
+			}
+			continue
+		}
+
+		if down == 0 {
+			falling = i
+		}
+		down = math.Max(0, down+reference-value)
+		if down > limit {
+			episodes = d.appendEpisode(episodes, counts, first, onset, falling, true, baseline)
+			inEpisode = false
+			up = 0
+		}
+	}
+	if inEpisode {
+		episodes = d.appendEpisode(episodes, counts, first, onset, len(counts), false, baseline)
+	}
+
+	return episodes
+}
+
+// appendEpisode adds the episode covering buckets [from, to) unless it has too few errors
+func (d *ChangePointDetector) appendEpisode(episodes []models.Episode, counts []int, first time.Time,
+	from, to int, recovered bool, baseline float64) []models.Episode {
+	total := 0
+	for _, count := range counts[from:to] {
+		total += count
+	}
+	if total < d.config.MinCount {
+		return episodes
+	}
+
+	minutes := d.bucket.Minutes()
+	episode := models.Episode{
+		Onset:        first.Add(time.Duration(from) * d.bucket),
+		Count:        total,
+		BaselineRate: baseline / minutes,
+		Rate:         float64(total) / (float64(to-from) * minutes),
+		Recovered:    recovered,
+	}
+	if recovered {
+		episode.Recovery = first.Add(time.Duration(to) * d.bucket)
+	}
+	return append(episodes, episode)
+}
+
+// dense returns the bucket counts from start to end, zero-filled
+func (d *ChangePointDetector) dense(series []models.TimeBucket, start, end time.Time) []int {
+	first := start.Truncate(d.bucket)
+	if end.Before(first) {
+		return nil
+	}
+	counts := make([]int, int(end.Sub(first)/d.bucket)+1)
+	for _, bucket := range series {
+		i := int(bucket.Start.Sub(first) / d.bucket)
+		if i >= 0 && i < len(counts) {
+			counts[i] += bucket.Count
+		}
+	}
+	return counts
+}
+
+// MergeSeries sums the time series of groups bucket by bucket
+func MergeSeries(groups []models.ErrorGroup) []models.TimeBucket {
+	buckets := make(map[time.Time]int)
+	for _, group := range groups {
+		for _, bucket := range group.TimeSeries {
+			buckets[bucket.Start] += bucket.Count
+		}
+	}
+
+	series := make([]models.TimeBucket, 0, len(buckets))
+	for start, count := range buckets {
+		series = append(series, models.TimeBucket{Start: start, Count: count})
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].Start.Before(series[j].Start)
+	})
+	return series
+}
+
+// median returns the median of counts
+func median(counts []int) float64 {
+	sorted := make([]int, len(counts))
+	copy(sorted, counts)
+	sort.Ints(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return float64(sorted[mid-1]+sorted[mid]) / 2
+	}
+	return float64(sorted[mid])
+}
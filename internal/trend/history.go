@@ -0,0 +1,221 @@
+package trend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"log-analyzer/pkg/models"
+)
+
+// historyVersion is bumped when the persisted history format changes
+const historyVersion = 1
+
+// History stores hourly error counts per group fingerprint and per service across runs.
+// Observed hours are recorded too, so hours without errors count as zeros in baselines.
+type History struct {
+	path      string
+	retention time.Duration
+
+	observed map[time.Time]bool
+	groups   map[string]map[time.Time]int
+	services map[string]map[time.Time]int
+}
+
+// historyFile is the persisted form of the history
+type historyFile struct {
+	Version  int                          `json:"version"`
+	Observed []time.Time                  `json:"observed"`
+	Groups   map[string]map[time.Time]int `json:"groups"`
+	Services map[string]map[time.Time]int `json:"services"`
+}
+
+// NewHistory creates a history persisted at path, keeping retention of hourly counts
+func NewHistory(path string, retention time.Duration) *History {
+	return &History{
+		path:      path,
+		retention: retention,
+		observed:  make(map[time.Time]bool),
+		groups:    make(map[string]map[time.Time]int),
+		services:  make(map[string]map[time.Time]int),
+	}
+}
+
+// Load reads the history; a missing file starts an empty history
+func (h *History) Load() error {
+	data, err := os.ReadFile(h.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var file historyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse history %s: %w", h.path, err)
+	}
+	if file.Version != historyVersion {
+		return fmt.Errorf("unsupported history version %d in %s", file.Version, h.path)
+	}
+
+	for _, hour := range file.Observed {
+		h.observed[hour] = true
+	}
+	for fp, counts := range file.Groups {
+		h.groups[fp] = counts
+	}
+	for service, counts := range file.Services {
+		h.services[service] = counts
+	}
+	return nil
+}
+
+// Save prunes hours older than the retention and writes the history, replacing the file atomically
+func (h *History) Save() error {
+	h.prune()
+
+	file := historyFile{
+		Version:  historyVersion,
+		Groups:   h.groups,
+		Services: h.services,
+	}
+	for hour := range h.observed {
+		file.Observed = append(file.Observed, hour)
+	}
+	sort.Slice(file.Observed, func(i, j int) bool {
+		return file.Observed[i].Before(file.Observed[j])
+	})
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal history: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	tmpPath := h.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := os.Rename(tmpPath, h.path); err != nil {
+		return fmt.Errorf("failed to replace history: %w", err)
+	}
+	return nil
+}
+
+// Record adds the hourly counts of the groups observed between start and end.
+// Counts already stored for an hour are kept if larger, so overlapping runs do not double count.
+func (h *History) Record(groups []models.ErrorGroup, start, end time.Time) {
+	for _, hour := range HoursBetween(start, end) {
+		h.observed[hour] = true
+	}
+
+	services := make(map[string]map[time.Time]int)
+	for _, group := range groups {
+		hourly := HourlyCounts(group.TimeSeries)
+		recordMax(h.groups, group.Fingerprint, hourly)
+
+		if services[group.ServiceName] == nil {
+			services[group.ServiceName] = make(map[time.Time]int)
+		}
+		for hour, count := range hourly {
+			services[group.ServiceName][hour] += count
+		}
+	}
+	for service, hourly := range services {
+		recordMax(h.services, service, hourly)
+	}
+}
+
+// recordMax stores counts under key, keeping the larger count of each hour
+func recordMax(series map[string]map[time.Time]int, key string, counts map[time.Time]int) {
+	if series[key] == nil {
+		series[key] = make(map[time.Time]int)
+	}
+	for hour, count := range counts {
+		if count > series[key][hour] {
+			series[key][hour] = count
+		}
+	}
+}
+
+// GroupSeries returns the stored hourly counts of a group
+func (h *History) GroupSeries(fingerprint string) map[time.Time]int {
+	return h.groups[fingerprint]
+}
+
+// ServiceSeries returns the stored hourly counts of a service
+func (h *History) ServiceSeries(service string) map[time.Time]int {
+	return h.services[service]
+}
+
+// ObservedBefore returns the observed hours before t, oldest first
+func (h *History) ObservedBefore(t time.Time) []time.Time {
+	var hours []time.Time
+	for hour := range h.observed {
+		if hour.Before(t) {
+			hours = append(hours, hour)
+		}
+	}
+	sort.Slice(hours, func(i, j int) bool {
+		return hours[i].Before(hours[j])
+	})
+	return hours
+}
+
+// prune drops hours older than the retention, relative to the latest observed hour
+func (h *History) prune() {
+	if h.retention <= 0 {
+		return
+	}
+
+	var latest time.Time
+	for hour := range h.observed {
+		if hour.After(latest) {
+			latest = hour
+		}
+	}
+	cutoff := latest.Add(-h.retention)
+
+	for hour := range h.observed {
+		if hour.Before(cutoff) {
+			delete(h.observed, hour)
+		}
+	}
+	for _, all := range []map[string]map[time.Time]int{h.groups, h.services} {
+		for key, counts := range all {
+			for hour := range counts {
+				if hour.Before(cutoff) {
+					delete(counts, hour)
+				}
+			}
+			if len(counts) == 0 {
+				delete(all, key)
+			}
+		}
+	}
+}
+
+// HourlyCounts sums a time series into UTC hours
+func HourlyCounts(series []models.TimeBucket) map[time.Time]int {
+	counts := make(map[time.Time]int)
+	for _, bucket := range series {
+		counts[bucket.Start.UTC().Truncate(time.Hour)] += bucket.Count
+	}
+	return counts
+}
+
+// HoursBetween returns the UTC hours from the one containing start to the one containing end
+func HoursBetween(start, end time.Time) []time.Time {
+	var hours []time.Time
+	for hour := start.UTC().Truncate(time.Hour); !hour.After(end.UTC()); hour = hour.Add(time.Hour) {
+		hours = append(hours, hour)
+	}
+	return hours
+}
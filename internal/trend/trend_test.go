@@ -0,0 +1,205 @@
+package trend
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"log-analyzer/pkg/models"
+)
+
+// hourlyGroup builds a group with count errors in each hour from start
+func hourlyGroup(fingerprint string, start time.Time, counts []int) models.ErrorGroup {
+	group := models.ErrorGroup{Fingerprint: fingerprint, ServiceName: "pp-slot-api"}
+	for i, count := range counts {
+		if count == 0 {
+			continue
+		}
+		group.TimeSeries = append(group.TimeSeries, models.TimeBucket{
+			Start: start.Add(time.Duration(i) * time.Hour),
+			Count: count,
+		})
+		group.TotalCount += count
+	}
+	return group
+}
+
+func repeat(count, hours int) []int {
+	counts := make([]int, hours)
+	for i := range counts {
+		counts[i] = count
+	}
+	return counts
+}
+
+func testConfig() Config {
+	return Config{AnomalyThreshold: 3, MinPercentChange: 50, MinCount: 3, EWMAAlpha: 0.3}
+}
+
+func TestDetectorFlagsSpikeAgainstHistory(t *testing.T) {
+	start := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	history := NewHistory(filepath.Join(t.TempDir(), "history.json"), 28*24*time.Hour)
+
+	// Three days of a steady group, plus a group that is absent except for one noisy hour
+	steady := repeat(2, 72)
+	steady[10] = 4
+	history.Record([]models.ErrorGroup{
+		hourlyGroup("v2:steady", start, steady),
+		hourlyGroup("v2:quiet", start, append(repeat(0, 71), 1)),
+	}, start, start.Add(71*time.Hour+59*time.Minute))
+
+	runStart := start.Add(72 * time.Hour)
+	runEnd := runStart.Add(5*time.Hour + 59*time.Minute)
+	detector := NewDetector(history, testConfig())
+
+	normal := detector.AnalyzeGroup(hourlyGroup("v2:steady", runStart, repeat(2, 6)), runStart, runEnd)
+	if normal == nil || normal.IsAnomalous {
+		t.Fatalf("steady group flagged: %+v", normal)
+	}
+	if normal.PreviousDayCount != 48 {
+		t.Errorf("previous day count = %d, want 48", normal.PreviousDayCount)
+	}
+	if normal.Method != MethodEWMA {
+		t.Errorf("method = %q, want ewma without a week of history", normal.Method)
+	}
+
+	// A small absolute count is still anomalous when the group is normally silent
+	spike := detector.AnalyzeGroup(hourlyGroup("v2:quiet", runStart, []int{0, 12, 12, 0, 0, 0}), runStart, runEnd)
+	if spike == nil || !spike.IsAnomalous {
+		t.Fatalf("spike not flagged: %+v", spike)
+	}
+
+	// Below min_count nothing is flagged
+	if tiny := detector.AnalyzeGroup(hourlyGroup("v2:new", runStart, []int{2}), runStart, runEnd); tiny.IsAnomalous {
+		t.Errorf("group below min count flagged: %+v", tiny)
+	}
+}
+
+func TestDetectorScoresSparseSeriesOverRunWindow(t *testing.T) {
+	start := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	history := NewHistory(filepath.Join(t.TempDir(), "history.json"), 28*24*time.Hour)
+
+	// Three days with one error every four hours
+	counts := make([]int, 72)
+	for i := 0; i < len(counts); i += 4 {
+		counts[i] = 1
+	}
+	history.Record([]models.ErrorGroup{hourlyGroup("v2:sparse", start, counts)}, start, start.Add(71*time.Hour+59*time.Minute))
+
+	runStart := start.Add(72 * time.Hour)
+	runEnd := runStart.Add(5*time.Hour + 59*time.Minute)
+	detector := NewDetector(history, testConfig())
+
+	// One extra error over six hours is within the noise of the series
+	if quiet := detector.AnalyzeGroup(hourlyGroup("v2:sparse", runStart, []int{1, 1, 0, 0, 1, 0}), runStart, runEnd); quiet == nil || quiet.IsAnomalous {
+		t.Fatalf("single extra error flagged: %+v", quiet)
+	}
+
+	// A burst spread over the run is flagged even though no single hour stands out much
+	burst := detector.AnalyzeGroup(hourlyGroup("v2:sparse", runStart, repeat(2, 6)), runStart, runEnd)
+	if burst == nil || !burst.IsAnomalous {
+		t.Fatalf("sustained burst not flagged: %+v", burst)
+	}
+}
+
+func TestSeasonalBaseline(t *testing.T) {
+	// Three weeks where 09:00 has 20 errors and every other hour 1
+	start := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	hours := 21 * 24
+	counts := repeat(1, hours)
+	for i := 9; i < hours; i += 24 {
+		counts[i] = 20
+	}
+	history := NewHistory(filepath.Join(t.TempDir(), "history.json"), 28*24*time.Hour)
+	history.Record([]models.ErrorGroup{hourlyGroup("v2:daily", start, counts)}, start, start.Add(time.Duration(hours-1)*time.Hour))
+
+	runStart := start.Add(time.Duration(hours+9) * time.Hour)
+	runEnd := runStart.Add(59 * time.Minute)
+	result := NewDetector(history, testConfig()).AnalyzeGroup(hourlyGroup("v2:daily", runStart, []int{20}), runStart, runEnd)
+	if result.Method != MethodSeasonal || result.BaselineRate != 20 {
+		t.Fatalf("baseline = %.1f (%s), want seasonal 20", result.BaselineRate, result.Method)
+	}
+	if result.IsAnomalous {
+		t.Errorf("usual daily burst flagged: %+v", result)
+	}
+}
+
+func TestHistoryPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	history := NewHistory(path, 24*time.Hour)
+	history.Record([]models.ErrorGroup{hourlyGroup("v2:a", start, repeat(3, 48))}, start, start.Add(47*time.Hour))
+	// A rerun over an overlapping window keeps the larger count instead of adding
+	history.Record([]models.ErrorGroup{hourlyGroup("v2:a", start.Add(47*time.Hour), []int{1})}, start.Add(47*time.Hour), start.Add(47*time.Hour))
+	if err := history.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	loaded := NewHistory(path, 24*time.Hour)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	series := loaded.GroupSeries("v2:a")
+	if len(series) != 25 {
+		t.Errorf("kept %d hours, want 25 within retention", len(series))
+	}
+	if got := series[start.Add(47*time.Hour)]; got != 3 {
+		t.Errorf("overlapping hour = %d, want 3", got)
+	}
+	if got := loaded.ServiceSeries("pp-slot-api")[start.Add(30*time.Hour)]; got != 3 {
+		t.Errorf("service hour = %d, want 3", got)
+	}
+	if len(loaded.ObservedBefore(start.Add(48*time.Hour))) != 25 {
+		t.Errorf("observed hours = %d, want 25", len(loaded.ObservedBefore(start.Add(48*time.Hour))))
+	}
+}
+
+func TestChangePointOnsetAndRecovery(t *testing.T) {
+	start := time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)
+	end := start.Add(4 * time.Hour)
+	onset := start.Add(2*time.Hour + 7*time.Minute)
+	recovery := start.Add(2*time.Hour + 40*time.Minute)
+
+	var steady, incident, ongoing []models.TimeBucket
+	for minute := start; minute.Before(end); minute = minute.Add(time.Minute) {
+		count := 1
+		if !minute.Before(onset) && minute.Before(recovery) {
+			count = 12
+			incident = append(incident, models.TimeBucket{Start: minute, Count: 12})
+		}
+		steady = append(steady, models.TimeBucket{Start: minute, Count: count})
+		if !minute.Before(end.Add(-20 * time.Minute)) {
+			ongoing = append(ongoing, models.TimeBucket{Start: minute, Count: 5})
+		}
+	}
+	detector := NewChangePointDetector(time.Minute, ChangePointConfig{Threshold: 5, Drift: 1, MinCount: 10})
+
+	episodes := detector.Detect(steady, start, end)
+	if len(episodes) != 1 {
+		t.Fatalf("got %d episodes, want 1: %+v", len(episodes), episodes)
+	}
+	e := episodes[0]
+	if !e.Onset.Equal(onset) || !e.Recovered || !e.Recovery.Equal(recovery) {
+		t.Errorf("episode %s - %s (recovered %v), want %s - %s", e.Onset, e.Recovery, e.Recovered, onset, recovery)
+	}
+	if e.RateRatio() != 12 {
+		t.Errorf("rate ratio = %.1f, want 12", e.RateRatio())
+	}
+
+	// A group that only exists during the incident has no baseline
+	if episodes := detector.Detect(incident, start, end); len(episodes) != 1 || episodes[0].RateRatio() != 0 || !episodes[0].Onset.Equal(onset) {
+		t.Errorf("incident-only group episodes = %+v", episodes)
+	}
+
+	// A shift still going at the end of the data is not recovered
+	if episodes := detector.Detect(ongoing, start, end); len(episodes) != 1 || episodes[0].Recovered {
+		t.Errorf("ongoing episodes = %+v", episodes)
+	}
+
+	// Flat series and series merged from groups without shifts have no episodes
+	flat := MergeSeries([]models.ErrorGroup{{TimeSeries: steady[:60]}, {TimeSeries: steady[:60]}})
+	if episodes := detector.Detect(flat, start, start.Add(59*time.Minute)); len(episodes) != 0 {
+		t.Errorf("flat series episodes = %+v", episodes)
+	}
+}
@@ -1,7 +1,10 @@
 package models
 
 import (
+	"strings"
 	"time"
+
+	"log-analyzer/pkg/sketch"
 )
 
 // Severity represents error severity levels
@@ -14,6 +17,47 @@ const (
 	SeverityCritical Severity = "critical"
 )
 
+// IsValid reports whether s is one of the known severities
+func (s Severity) IsValid() bool {
+	switch s {
+	case SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical:
+		return true
+	}
+	return false
+}
+
+// LogLevel represents a canonical log level
+type LogLevel string
+
+const (
+	LevelTrace LogLevel = "trace"
+	LevelDebug LogLevel = "debug"
+	LevelInfo  LogLevel = "info"
+	LevelWarn  LogLevel = "warn"
+	LevelError LogLevel = "error"
+	LevelFatal LogLevel = "fatal"
+)
+
+// levelRanks orders canonical levels from least to most severe
+var levelRanks = map[LogLevel]int{
+	LevelTrace: 1,
+	LevelDebug: 2,
+	LevelInfo:  3,
+	LevelWarn:  4,
+	LevelError: 5,
+	LevelFatal: 6,
+}
+
+// Rank returns the severity order of the level (0 for unknown levels)
+func (l LogLevel) Rank() int {
+	return levelRanks[l]
+}
+
+// IsValid reports whether the level is one of the canonical levels
+func (l LogLevel) IsValid() bool {
+	return l.Rank() > 0
+}
+
 // RawLog represents a raw log entry from OpenSearch
 type RawLog struct {
 	Index     string           `json:"_index"`
@@ -24,14 +68,15 @@ type RawLog struct {
 
 // OpenSearchSource represents the _source field from OpenSearch
 type OpenSearchSource struct {
-	Event     EventData              `json:"event"`
-	Message   string                 `json:"message"`
-	Fields    FieldsData             `json:"fields"`
-	Agent     map[string]interface{} `json:"agent,omitempty"`
-	Tags      []string               `json:"tags,omitempty"`
-	Log       map[string]interface{} `json:"log,omitempty"`
-	Host      map[string]interface{} `json:"host,omitempty"`
-	Timestamp time.Time              `json:"@timestamp"`
+	Event      EventData              `json:"event"`
+	Message    string                 `json:"message"`
+	Fields     FieldsData             `json:"fields"`
+	Agent      map[string]interface{} `json:"agent,omitempty"`
+	Tags       []string               `json:"tags,omitempty"`
+	Log        map[string]interface{} `json:"log,omitempty"`
+	Host       map[string]interface{} `json:"host,omitempty"`
+	Kubernetes map[string]interface{} `json:"kubernetes,omitempty"`
+	Timestamp  time.Time              `json:"@timestamp"`
 }
 
 // EventData represents the event field
@@ -46,13 +91,22 @@ type FieldsData struct {
 
 // ParsedLog represents a parsed log entry (clean JSON)
 type ParsedLog struct {
-	Timestamp   time.Time `json:"@timestamp"`
-	Caller      string    `json:"caller"`
-	Content     string    `json:"content"`
-	Level       string    `json:"level"`
-	Span        string    `json:"span"`
-	Trace       string    `json:"trace"`
-	ServiceName string    `json:"service_name"`
+	Timestamp time.Time `json:"@timestamp"` // always UTC
+	// TimestampSource tells which source the timestamp came from (inner, wrapper, source)
+	TimestampSource string `json:"timestamp_source,omitempty"`
+	// ClockSkew is the inner (application) timestamp minus the outer (runtime/shipper) one, if both exist
+	ClockSkew     *time.Duration `json:"clock_skew,omitempty"`
+	Caller        string         `json:"caller"`
+	Content       string         `json:"content"`
+	Level         LogLevel       `json:"level"`
+	OriginalLevel string         `json:"original_level,omitempty"`
+	Span          string         `json:"span"`
+	Trace         string         `json:"trace"`
+	ServiceName   string         `json:"service_name"`
+	Namespace     string         `json:"namespace,omitempty"`
+	Pod           string         `json:"pod,omitempty"`
+	Container     string         `json:"container,omitempty"`
+	Node          string         `json:"node,omitempty"`
 }
 
 // PeakWindow represents a time window with high error density
@@ -63,16 +117,120 @@ type PeakWindow struct {
 	Density float64   `json:"density"` // errors per minute
 }
 
+// Episode is a period where an error rate shifted above its baseline, found by change-point detection.
+// Rates are errors per minute; Recovery is only set once the rate returned to its baseline.
+type Episode struct {
+	Onset        time.Time `json:"onset"`
+	Recovery     time.Time `json:"recovery"`
+	Recovered    bool      `json:"recovered"`
+	Count        int       `json:"count"`
+	BaselineRate float64   `json:"baseline_rate"`
+	Rate         float64   `json:"rate"`
+}
+
+// RateRatio is the episode rate as a multiple of the baseline rate; zero when there was no baseline
+func (e Episode) RateRatio() float64 {
+	if e.BaselineRate == 0 {
+		return 0
+	}
+	return e.Rate / e.BaselineRate
+}
+
+// ErrorRatio relates error counts to the total log or request volume of their service
+type ErrorRatio struct {
+	Errors    int       `json:"errors"`
+	Total     int       `json:"total"`
+	Ratio     float64   `json:"ratio"`      // Errors / Total over the run
+	PeakRatio float64   `json:"peak_ratio"` // highest hourly ratio among hours with enough volume
+	PeakHour  time.Time `json:"peak_hour"`
+}
+
+// BurnRate is the SLO error-budget burn rate over a long and a short window ending at the end of the run.
+// An alert fires when both windows burn at or above Threshold.
+type BurnRate struct {
+	Window      time.Duration `json:"window"`
+	ShortWindow time.Duration `json:"short_window"`
+	Rate        float64       `json:"rate"`
+	ShortRate   float64       `json:"short_rate"`
+	Threshold   float64       `json:"threshold"`
+	Target      float64       `json:"target"` // SLO target, e.g. 0.999
+	Severity    Severity      `json:"severity"`
+	Firing      bool          `json:"firing"`
+}
+
+// DeployEvent is a deploy or other change of a service, ingested from files, the CLI or the webhook
+type DeployEvent struct {
+	Service     string    `json:"service" yaml:"service"`
+	Version     string    `json:"version" yaml:"version"`
+	Time        time.Time `json:"time" yaml:"time"`
+	Description string    `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// DeployMatch links a group onset (first occurrence or episode onset) to the deploy before it
+type DeployMatch struct {
+	Event DeployEvent   `json:"event"`
+	Onset time.Time     `json:"onset"`
+	Lag   time.Duration `json:"lag"` // onset minus deploy time
+}
+
 // ErrorGroup represents a group of deduplicated errors
 type ErrorGroup struct {
-	Fingerprint       string         `json:"fingerprint"`
-	NormalizedContent string         `json:"normalized_content"`
-	ServiceName       string         `json:"service_name"`
-	CallerFile        string         `json:"caller_file"`
-	TotalCount        int            `json:"total_count"`
-	Samples           []ParsedLog    `json:"samples"`
-	TimeDistribution  map[string]int `json:"time_distribution"`
-	PeakWindow        *PeakWindow    `json:"peak_window"`
+	Fingerprint        string             `json:"fingerprint"`
+	NormalizedContent  string             `json:"normalized_content"`
+	ServiceName        string             `json:"service_name"`
+	CallerFile         string             `json:"caller_file"`
+	Level              LogLevel           `json:"level"` // most severe level seen in the group
+	TotalCount         int                `json:"total_count"`
+	Samples            []ParsedLog        `json:"samples"`
+	TimeDistribution   map[string]int     `json:"time_distribution"` // "HH:00" (display timezone) -> count, across days
+	TimeSeries         []TimeBucket       `json:"time_series,omitempty"`
+	FirstSeen          time.Time          `json:"first_seen"`
+	LastSeen           time.Time          `json:"last_seen"`
+	PodCounts          map[string]int     `json:"pod_counts,omitempty"` // pod -> count
+	PeakWindow         *PeakWindow        `json:"peak_window"`
+	ErrorChain         []string           `json:"error_chain,omitempty"`         // wrapped error chain, outermost first
+	RootCause          string             `json:"root_cause,omitempty"`          // innermost cause of the chain
+	MergedFingerprints []string           `json:"merged_fingerprints,omitempty"` // near-duplicate groups folded into this one
+	MaskedValues       []MaskedValueStats `json:"masked_values,omitempty"`       // most common values behind placeholders
+	Episodes           []Episode          `json:"episodes,omitempty"`            // periods where the rate shifted above its baseline
+	Deploy             *DeployMatch       `json:"deploy,omitempty"`              // deploy of the same service shortly before the group started
+	ErrorRatio         *ErrorRatio        `json:"error_ratio,omitempty"`         // errors relative to the service's total volume
+	// DistinctCounts is the approximate number of distinct affected entities (trace, pod, host, configured fields)
+	DistinctCounts map[string]int `json:"distinct_counts,omitempty"`
+	// EntitySketches hold the distinct-count sketches behind DistinctCounts, so groups can be merged
+	EntitySketches map[string]*sketch.HyperLogLog `json:"-"`
+	// TraceFirstSeen maps trace IDs to their first occurrence in the group (used for correlation)
+	TraceFirstSeen map[string]time.Time `json:"-"`
+}
+
+// TimeBucket is the number of errors in the time series bucket starting at Start
+type TimeBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// ValueCount is a value with its (approximate) number of occurrences
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// MaskedValueStats lists the most common original values at a placeholder position
+type MaskedValueStats struct {
+	Position  string       `json:"position"` // e.g. "<NUM>#2": the second <NUM> in the normalized message
+	TopValues []ValueCount `json:"top_values"`
+}
+
+// ShortFingerprint returns the 8-character short form of a fingerprint, without its version prefix
+// (e.g. "v2:9f86d081..." -> "9f86d081")
+func ShortFingerprint(fingerprint string) string {
+	if i := strings.IndexByte(fingerprint, ':'); i >= 0 {
+		fingerprint = fingerprint[i+1:]
+	}
+	if len(fingerprint) > 8 {
+		return fingerprint[:8]
+	}
+	return fingerprint
 }
 
 // TrendAnalysis represents trend comparison with historical data
@@ -80,6 +238,16 @@ type TrendAnalysis struct {
 	PreviousDayCount int     `json:"previous_day_count"`
 	PercentageChange float64 `json:"percentage_change"`
 	IsAnomalous      bool    `json:"is_anomalous"`
+
+	// Rates are errors per hour; BaselineRate is the expected rate chosen by Method
+	ObservedRate float64 `json:"observed_rate"`
+	BaselineRate float64 `json:"baseline_rate"`
+	Method       string  `json:"method"`
+	Mean         float64 `json:"mean"`
+	StdDev       float64 `json:"stddev"`
+	EWMA         float64 `json:"ewma"`
+	Seasonal     float64 `json:"seasonal,omitempty"`
+	ZScore       float64 `json:"z_score"`
 }
 
 // Analysis represents the analysis result for an error group
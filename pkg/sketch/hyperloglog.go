@@ -0,0 +1,114 @@
+// Package sketch provides bounded-memory approximate counting structures
+package sketch
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// DefaultPrecision uses 2^11 registers (2 KiB), a standard error of about 2.3%
+const DefaultPrecision = 11
+
+// HyperLogLog estimates the number of distinct values added to it in fixed memory.
+// Sketches with the same precision can be merged to count the union.
+type HyperLogLog struct {
+	precision uint8
+	registers []uint8
+}
+
+// NewHyperLogLog creates a sketch with 2^precision registers (precision between 4 and 16)
+func NewHyperLogLog(precision uint8) *HyperLogLog {
+	if precision < 4 || precision > 16 {
+		precision = DefaultPrecision
+	}
+	return &HyperLogLog{
+		precision: precision,
+		registers: make([]uint8, 1<<precision),
+	}
+}
+
+// Add records a value
+func (h *HyperLogLog) Add(value string) {
+	x := hash64(value)
+
+	// The first precision bits select the register, the rest give the rank
+	index := x >> (64 - h.precision)
+	rank := uint8(1)
+	for w := x << h.precision; w&(1<<63) == 0 && rank <= 64-h.precision; w <<= 1 {
+		rank++
+	}
+
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+	}
+}
+
+// Merge folds other into h, so h estimates the union of both.
+// Sketches of different precision are not merged.
+func (h *HyperLogLog) Merge(other *HyperLogLog) {
+	if other == nil || other.precision != h.precision {
+		return
+	}
+	for i, r := range other.registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+		}
+	}
+}
+
+// Clone returns an independent copy of the sketch
+func (h *HyperLogLog) Clone() *HyperLogLog {
+	return &HyperLogLog{
+		precision: h.precision,
+		registers: append([]uint8(nil), h.registers...),
+	}
+}
+
+// Count returns the estimated number of distinct values
+func (h *HyperLogLog) Count() int {
+	m := float64(len(h.registers))
+
+	sum, zeros := 0.0, 0
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := alpha(m) * m * m / sum
+
+	// Small ranges are estimated far better by linear counting
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+
+	return int(math.Round(estimate))
+}
+
+// alpha is the bias correction constant for m registers
+func alpha(m float64) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	}
+	return 0.7213 / (1 + 1.079/m)
+}
+
+// hash64 hashes a value with FNV-1a, finalized with the splitmix64 mixer
+// so every bit depends on the whole input
+func hash64(value string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(value))
+	x := h.Sum64()
+
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,47 @@
+package sketch
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestHyperLogLogCount(t *testing.T) {
+	for _, n := range []int{1, 10, 1000, 100000} {
+		h := NewHyperLogLog(DefaultPrecision)
+		for i := 0; i < n; i++ {
+			h.Add(fmt.Sprintf("player-%d", i))
+			h.Add(fmt.Sprintf("player-%d", i)) // duplicates do not count
+		}
+
+		// Standard error is about 2.3%; allow three of them
+		got := h.Count()
+		if relErr := math.Abs(float64(got-n)) / float64(n); relErr > 0.07 {
+			t.Errorf("n=%d: estimate %d is %.1f%% off", n, got, relErr*100)
+		}
+	}
+}
+
+func TestHyperLogLogMerge(t *testing.T) {
+	a := NewHyperLogLog(DefaultPrecision)
+	b := NewHyperLogLog(DefaultPrecision)
+	for i := 0; i < 500; i++ {
+		a.Add(fmt.Sprintf("trace-%d", i))
+		b.Add(fmt.Sprintf("trace-%d", i+250))
+	}
+
+	union := a.Clone()
+	union.Merge(b)
+	if got := union.Count(); math.Abs(float64(got-750)) > 750*0.05 {
+		t.Errorf("expected about 750 distinct values, got %d", got)
+	}
+	if got := a.Count(); math.Abs(float64(got-500)) > 500*0.05 {
+		t.Errorf("merging into a clone changed the original: %d", got)
+	}
+}
+
+func TestHyperLogLogEmpty(t *testing.T) {
+	if got := NewHyperLogLog(DefaultPrecision).Count(); got != 0 {
+		t.Errorf("expected 0 for an empty sketch, got %d", got)
+	}
+}